@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// systemConfigFile is read before the user file. It is a variable so that
+// tests can point it elsewhere.
+var systemConfigFile = "/etc/godu.conf"
+
+/*
+Configuration files use the same format as ncdu: every non-empty line holds a
+single long (or short) option, optionally followed by its argument, exactly as
+it would be given on the command line. Lines starting with '#' are comments.
+
+	# default excludes for the whole team
+	--exclude .git
+	--exclude node_modules
+	--color dark
+	--sort=name-asc
+
+The options are placed in front of the command line arguments, so the command
+line always takes precedence over the configuration files, and the user file
+takes precedence over the system file.
+*/
+
+// configPaths returns the configuration files in the order they are applied.
+func configPaths() []string {
+	paths := []string{systemConfigFile}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "godu", "config"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "godu", "config"))
+	}
+	return paths
+}
+
+// ignoreConfig reports whether --ignore-config is present in argv. It has to
+// be checked before cobra parses anything since the configuration files are
+// fed into that same parse.
+func ignoreConfig(argv []string) bool {
+	for _, arg := range argv {
+		switch arg {
+		case "--":
+			return false
+		case "--ignore-config", "--ignore-config=true":
+			return true
+		}
+	}
+	return false
+}
+
+// buildArgs returns argv prefixed with the options of every configuration
+// file that exists, unless --ignore-config was given. When argv starts with
+// a subcommand, the options go after it.
+func buildArgs(argv []string) ([]string, error) {
+	if ignoreConfig(argv) {
+		return argv, nil
+	}
+	args := []string{}
+	if len(argv) > 0 {
+		if cmd, _, err := rootCmd.Find(argv[:1]); err == nil && cmd != rootCmd {
+			args, argv = append(args, argv[0]), argv[1:]
+		}
+	}
+	for _, path := range configPaths() {
+		opts, err := loadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, opts...)
+	}
+	return append(args, argv...), nil
+}
+
+// loadConfig reads the options in path. A missing file is not an error.
+func loadConfig(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %w", err)
+	}
+	defer file.Close()
+	return parseConfig(file, path)
+}
+
+// parseConfig turns the lines of a configuration file into command line
+// arguments. name is only used for error messages.
+func parseConfig(r io.Reader, name string) ([]string, error) {
+	args := []string{}
+	reader := bufio.NewScanner(r)
+	line := 0
+	for reader.Scan() {
+		line++
+		text := strings.TrimSpace(reader.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if !strings.HasPrefix(text, "-") {
+			return nil, fmt.Errorf("%s:%d: expected an option, got %q", name, line, text)
+		}
+
+		opt, value := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			opt, value = text[:i], strings.TrimSpace(text[i:])
+		}
+		args = append(args, opt)
+		if value != "" {
+			args = append(args, unquote(value))
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %w", name, err)
+	}
+	return args, nil
+}
+
+// unquote strips one level of matching single or double quotes so patterns
+// with leading or trailing spaces can be written in a config file.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"internal/tui"
+)
+
+// writeConfigs points the system and user configuration files at temporary
+// files holding system and user, or at missing files when they are empty.
+func writeConfigs(t *testing.T, system, user string) {
+	t.Helper()
+	dir := t.TempDir()
+	old := systemConfigFile
+	systemConfigFile = filepath.Join(dir, "godu.conf")
+	t.Cleanup(func() { systemConfigFile = old })
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	if system != "" {
+		if err := os.WriteFile(systemConfigFile, []byte(system), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if user != "" {
+		if err := os.MkdirAll(filepath.Join(dir, "godu"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "godu", "config"), []byte(user), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// parseArgs resets the options and parses args with the flags of godu.
+func parseArgs(args []string) error {
+	opts = defaultOptions()
+	return rootCmd.PersistentFlags().Parse(args)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		system, user string
+		argv         []string
+		extended     bool
+		color        tui.ColorScheme
+		exclude      []string
+	}{
+		{
+			name:     "system only",
+			system:   "--extended\n--color off\n",
+			extended: true,
+			color:    tui.ColorOff,
+		},
+		{
+			name:   "user overrides system",
+			system: "--extended\n--color off\n",
+			user:   "--no-extended\n--color dark\n",
+			color:  tui.ColorDark,
+		},
+		{
+			name:   "argv overrides user",
+			system: "--no-extended\n",
+			user:   "-e\n--color off\n",
+			argv:   []string{"--no-extended", "--color=dark"},
+			color:  tui.ColorDark,
+		},
+		{
+			name:     "last flag of a pair wins within argv",
+			user:     "--extended\n",
+			argv:     []string{"--no-extended", "-e"},
+			extended: true,
+			color:    tui.ColorDarkBg,
+		},
+		{
+			name:    "patterns add up",
+			system:  "--exclude .git\n",
+			user:    "--exclude node_modules\n",
+			argv:    []string{"--exclude", "*.o"},
+			color:   tui.ColorDarkBg,
+			exclude: []string{".git", "node_modules", "*.o"},
+		},
+		{
+			name:   "ignore-config",
+			system: "--extended\n",
+			user:   "--color off\n--exclude .git\n",
+			argv:   []string{"--ignore-config"},
+			color:  tui.ColorDarkBg,
+		},
+	}
+	// defaultOptions turns colors off when NO_COLOR is set at all
+	t.Setenv("NO_COLOR", "")
+	os.Unsetenv("NO_COLOR")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigs(t, tt.system, tt.user)
+			args, err := buildArgs(tt.argv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := parseArgs(args); err != nil {
+				t.Fatalf("parsing %q: %v", args, err)
+			}
+			if opts.Scan.Extended != tt.extended {
+				t.Errorf("extended = %t, want %t", opts.Scan.Extended, tt.extended)
+			}
+			if opts.UI.Color != tt.color {
+				t.Errorf("color = %s, want %s", opts.UI.Color, tt.color)
+			}
+			if len(opts.Scan.Exclude) != 0 || len(tt.exclude) != 0 {
+				if !reflect.DeepEqual(opts.Scan.Exclude, tt.exclude) {
+					t.Errorf("exclude = %q, want %q", opts.Scan.Exclude, tt.exclude)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildArgsSubcommand(t *testing.T) {
+	writeConfigs(t, "--exclude .git\n", "--si\n")
+	tests := []struct {
+		argv []string
+		want []string
+	}{
+		{
+			argv: []string{"top", "-n", "5"},
+			want: []string{"top", "--exclude", ".git", "--si", "-n", "5"},
+		},
+		{
+			argv: []string{"/srv"},
+			want: []string{"--exclude", ".git", "--si", "/srv"},
+		},
+		{
+			argv: []string{},
+			want: []string{"--exclude", ".git", "--si"},
+		},
+		{
+			argv: []string{"top", "--ignore-config"},
+			want: []string{"top", "--ignore-config"},
+		},
+	}
+	for _, tt := range tests {
+		args, err := buildArgs(tt.argv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(args, tt.want) {
+			t.Errorf("buildArgs(%q) = %q, want %q", tt.argv, args, tt.want)
+		}
+	}
+}
+
+func TestIgnoreConfig(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want bool
+	}{
+		{[]string{"--ignore-config"}, true},
+		{[]string{"-e", "--ignore-config=true", "/srv"}, true},
+		{[]string{"--", "--ignore-config"}, false},
+		{[]string{"--ignore-config=false"}, false},
+		{[]string{"-e"}, false},
+	}
+	for _, tt := range tests {
+		if got := ignoreConfig(tt.argv); got != tt.want {
+			t.Errorf("ignoreConfig(%q) = %t, want %t", tt.argv, got, tt.want)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+		err    string
+	}{
+		{
+			name:   "comments and blank lines",
+			config: "# defaults\n\n  --extended  \n\t# indented comment\n-x\n",
+			want:   []string{"--extended", "-x"},
+		},
+		{
+			name:   "values",
+			config: "--exclude node_modules\n--sort=name-asc\n--color\tdark\n",
+			want:   []string{"--exclude", "node_modules", "--sort=name-asc", "--color", "dark"},
+		},
+		{
+			name:   "quoting",
+			config: "--exclude \" leading space\"\n--exclude 'single quoted'\n--exclude \"unbalanced\n--exclude a b\n",
+			want:   []string{"--exclude", " leading space", "--exclude", "single quoted", "--exclude", "\"unbalanced", "--exclude", "a b"},
+		},
+		{
+			name:   "not an option",
+			config: "--extended\nexclude .git\n",
+			err:    "test.conf:2: expected an option",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := parseConfig(strings.NewReader(tt.config), "test.conf")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("args = %q, want %q", args, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigUnknownOption(t *testing.T) {
+	writeConfigs(t, "", "--extended\n--no-such-option\n")
+	args, err := buildArgs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = parseArgs(args)
+	if err == nil || !strings.Contains(err.Error(), "no-such-option") {
+		t.Fatalf("error = %v, want an unknown flag error", err)
+	}
+}
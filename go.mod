@@ -8,7 +8,7 @@ require (
 	github.com/charmbracelet/bubbletea v0.22.0 // direct
 	github.com/charmbracelet/lipgloss v0.5.0 // indirect
 	github.com/spf13/cobra v1.5.0
-	internal/du v1.0.0
+	golang.org/x/sys v0.0.0-20220708085239-5a0f0661e09d
 	internal/tui v1.0.0
 )
 
@@ -27,12 +27,7 @@ require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/sahilm/fuzzy v0.1.0 // indirect
 	github.com/spf13/pflag v1.0.5
-	golang.org/x/sys v0.0.0-20220708085239-5a0f0661e09d // indirect
 	golang.org/x/term v0.0.0-20220526004731-065cf7ba2467 // indirect
 )
 
-require github.com/google/go-cmp v0.5.8 // indirect
-
 replace internal/tui => ./internal/tui
-
-replace internal/du => ./internal/du
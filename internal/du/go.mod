@@ -1,3 +0,0 @@
-module du
-
-go 1.17
@@ -0,0 +1,273 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+type shellDoneMsg struct{ err error }
+
+// handleKey runs the action bound to msg, if any.
+func (m *Model) handleKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.open):
+		if i, ok := m.list.SelectedItem().(item); ok {
+			if i.name == ".." {
+				m.leave()
+			} else if i.isDir {
+				m.enter(i.name)
+			}
+		}
+
+	case key.Matches(msg, m.keys.parent):
+		m.leave()
+
+	case key.Matches(msg, m.keys.sortName):
+		m.sortBy(Name)
+	case key.Matches(msg, m.keys.sortSize):
+		if m.UseApparentSize {
+			m.sortBy(ApparentSize)
+		} else {
+			m.sortBy(Size)
+		}
+	case key.Matches(msg, m.keys.sortItemCount):
+		m.sortBy(ItemCount)
+	case key.Matches(msg, m.keys.sortMTime):
+		m.sortBy(ModTime)
+
+	case key.Matches(msg, m.keys.toggleApparent):
+		m.UseApparentSize = !m.UseApparentSize
+		switch {
+		case m.UseApparentSize && m.ListOrder == Size:
+			m.ListOrder = ApparentSize
+		case !m.UseApparentSize && m.ListOrder == ApparentSize:
+			m.ListOrder = Size
+		}
+		m.refresh()
+	case key.Matches(msg, m.keys.toggleItemCount):
+		m.ShowItemCount = !m.ShowItemCount
+		m.refresh()
+	case key.Matches(msg, m.keys.toggleMTime):
+		m.ShowMTime = !m.ShowMTime
+		m.refresh()
+	case key.Matches(msg, m.keys.toggleGraph):
+		// both -> graph -> percent -> none -> both
+		switch {
+		case m.ShowGraph && m.ShowPercent:
+			m.ShowPercent = false
+		case m.ShowGraph:
+			m.ShowGraph, m.ShowPercent = false, true
+		case m.ShowPercent:
+			m.ShowPercent = false
+		default:
+			m.ShowGraph, m.ShowPercent = true, true
+		}
+		m.refresh()
+	case key.Matches(msg, m.keys.toggleHidden):
+		m.ShowHidden = !m.ShowHidden
+		m.refresh()
+	case key.Matches(msg, m.keys.toggleDirsFirst):
+		m.DirectoryFirst = !m.DirectoryFirst
+		m.refresh()
+
+	case key.Matches(msg, m.keys.delete):
+		return true, m.deleteSelected()
+	case key.Matches(msg, m.keys.shell):
+		return true, m.spawnShell()
+	case key.Matches(msg, m.keys.refresh):
+		return true, m.refreshCurrent()
+	case key.Matches(msg, m.keys.info):
+		m.showInfo()
+	case key.Matches(msg, m.keys.help):
+		m.showHelp = true
+	case key.Matches(msg, m.keys.mark):
+		m.toggleMark()
+	case key.Matches(msg, m.keys.viewMarked):
+		return true, m.showMarked()
+	case key.Matches(msg, m.keys.search):
+		return true, m.openSearch()
+	case key.Matches(msg, m.keys.treemap):
+		m.treemap = &treemap{keys: newTreemapKeys()}
+		if i, ok := m.list.SelectedItem().(item); ok {
+			m.treemap.selected = i.name
+		}
+	case key.Matches(msg, m.keys.largest):
+		return true, m.showLargest()
+	case key.Matches(msg, m.keys.fileTypes):
+		return true, m.showTypes(m.Tree.ByExtension(m.Current), false)
+	case key.Matches(msg, m.keys.ages):
+		return true, m.showAges(false)
+	case key.Matches(msg, m.keys.owners):
+		return true, m.showOwners(false)
+	case key.Matches(msg, m.keys.duplicates):
+		return true, m.findDuplicates()
+	case key.Matches(msg, m.keys.identicalFolders):
+		return true, m.findIdenticalFolders()
+
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// sortBy sorts on order, or reverses the order if already sorted on it.
+func (m *Model) sortBy(order Order) {
+	if m.ListOrder == order {
+		m.Descending = !m.Descending
+	} else {
+		m.ListOrder = order
+		m.Descending = order != Name
+	}
+	m.refresh()
+}
+
+func (m *Model) status(format string, a ...interface{}) tea.Cmd {
+	return m.list.NewStatusMessage(statusMessageStyle(fmt.Sprintf(format, a...)))
+}
+
+func (m *Model) deleteSelected() tea.Cmd {
+	i, ok := m.list.SelectedItem().(item)
+	if !ok || i.name == ".." {
+		return nil
+	}
+	if !m.EnableDelete {
+		return m.status("Deletion is disabled")
+	}
+	if m.inArchive() {
+		return m.status("Deletion is disabled inside archives")
+	}
+	return m.confirm(fmt.Sprintf("Are you sure you want to delete %q?", i.name), func(m *Model) tea.Cmd {
+		return m.remove(i.name)
+	})
+}
+
+// remove deletes the entry called name in the current folder.
+func (m *Model) remove(name string) tea.Cmd {
+	id, ok := m.Tree.Child(m.Current, name)
+	if !ok {
+		return m.status("error deleting %s: not found", name)
+	}
+	if err := m.Tree.Delete(id); err != nil {
+		return m.status("%v", err)
+	}
+	m.pruneMarks()
+	m.refresh()
+	return m.status("Deleted %s", name)
+}
+
+func (m *Model) spawnShell() tea.Cmd {
+	if !m.EnableShell {
+		return m.status("Shell spawning is disabled")
+	}
+	if m.inArchive() {
+		return m.status("Shell spawning is disabled inside archives")
+	}
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	c := exec.Command(shell)
+	c.Dir = m.Tree.Path(m.Current)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return shellDoneMsg{err}
+	})
+}
+
+// refreshCurrent rescans the current folder.
+func (m *Model) refreshCurrent() tea.Cmd {
+	if !m.EnableRefresh {
+		return m.status("Refreshing is disabled")
+	}
+	if m.inArchive() || !m.Tree.Local() {
+		return m.status("Refreshing is disabled inside archives")
+	}
+	path := m.Tree.Path(m.Current)
+	// a refresh reads everything again rather than trusting the cache
+	opts := m.Scan
+	opts.CacheDir = ""
+	tree, err := ScanTree(path, opts)
+	if err != nil {
+		return m.status("%v", err)
+	}
+	m.Current = m.Tree.Replace(m.Current, tree)
+	m.pruneMarks()
+	m.refresh()
+	if err := m.watchTree(m.Current); err != nil {
+		return m.watchLimitStatus()
+	}
+	return m.status("Refreshed %s", path)
+}
+
+// inArchive reports whether the current folder is an archive or inside one,
+// where nothing can be changed.
+func (m *Model) inArchive() bool {
+	return m.Tree.IsArchive(m.Current) || m.Tree.InArchive(m.Current)
+}
+
+// showInfo opens a dialog with the details of the selected entry.
+func (m *Model) showInfo() {
+	i, ok := m.list.SelectedItem().(item)
+	if !ok || i.name == ".." {
+		return
+	}
+
+	t := m.Tree
+	id, ok := t.Child(m.Current, i.name)
+	if !ok {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name:       %s\n", t.Name(id))
+	fmt.Fprintf(&b, "Path:       %s\n", t.Path(id))
+	switch {
+	case t.IsArchive(id):
+		fmt.Fprintf(&b, "Type:       archive\n")
+	case t.InArchive(id) && t.IsDir(id):
+		fmt.Fprintf(&b, "Type:       directory in archive\n")
+	case t.InArchive(id):
+		fmt.Fprintf(&b, "Type:       %s in archive\n", fileType(t.Mode(id)))
+	case t.IsDir(id):
+		fmt.Fprintf(&b, "Type:       directory\n")
+	default:
+		fmt.Fprintf(&b, "Type:       %s\n", fileType(t.Mode(id)))
+	}
+	fmt.Fprintf(&b, "Mode:       %s\n", t.Mode(id))
+	fmt.Fprintf(&b, "Owner:      %s:%s\n", UserName(t.UID(id)), GroupName(t.GID(id)))
+	fmt.Fprintf(&b, "Modified:   %s\n", t.ModTime(id).Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Disk usage: %s (%d B)\n", m.formatSize(t.Size(id)), t.Size(id))
+	fmt.Fprintf(&b, "Apparent:   %s (%d B)", m.formatSize(t.ApparentSize(id)), t.ApparentSize(id))
+	if t.IsDir(id) {
+		fmt.Fprintf(&b, "\nItems:      %d", t.Items(id))
+	}
+	m.dialog = &dialog{
+		message: b.String(),
+		choices: []choice{{
+			binding: key.NewBinding(key.WithKeys("esc", "enter", "q", "i"), key.WithHelp("esc", "close")),
+			action: func(m Model) (Model, tea.Cmd) {
+				return m, nil
+			},
+		}},
+	}
+}
+
+func fileType(mode os.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	}
+	return "other"
+}
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+// ageItem is a bucket of the age histogram.
+type ageItem struct {
+	title  string
+	bucket AgeBucket
+}
+
+func (i ageItem) Title() string       { return i.title }
+func (i ageItem) Description() string { return "" }
+func (i ageItem) FilterValue() string { return i.bucket.Label }
+
+// formatAge shows a duration in whole days where possible.
+func formatAge(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// showAges opens the age histogram of the current folder, by modification
+// time or, in extended mode, by access time. The tab key switches between
+// the two.
+func (m *Model) showAges(useAccessTime bool) tea.Cmd {
+	now := time.Now()
+	path := m.Tree.Path(m.Current)
+	buckets := m.Tree.AgeHistogram(m.Current, now, useAccessTime)
+	kind := "modification time"
+	if useAccessTime {
+		kind = "access time"
+	}
+	title := func(m *Model) string {
+		return fmt.Sprintf("Age by %s | %s", kind, path)
+	}
+	items := func(m *Model) []list.Item {
+		// drop what was deleted since the histogram was made
+		current := make([]AgeBucket, len(buckets))
+		var total, largest int64
+		for i, b := range buckets {
+			c := AgeBucket{Label: b.Label, Min: b.Min, Max: b.Max, Files: []Entry{}}
+			for _, f := range b.Files {
+				if m.Tree.Contains(f.ID) {
+					c.Files = append(c.Files, f)
+					c.Size += f.Size
+					c.ApparentSize += f.ApparentSize
+				}
+			}
+			current[i] = c
+			size := m.size(c.Size, c.ApparentSize)
+			total += size
+			if size > largest {
+				largest = size
+			}
+		}
+
+		items := []list.Item{}
+		for _, b := range current {
+			size := m.size(b.Size, b.ApparentSize)
+			percent, fill := 0.0, 0.0
+			if total > 0 {
+				percent = float64(size) / float64(total) * 100
+				fill = float64(size) / float64(largest)
+			}
+			items = append(items, ageItem{
+				title: fmt.Sprintf("%-16s %8s %5.1f%% [%s] %8d files",
+					b.Label, m.formatSize(size), percent, m.graph(fill, 20), len(b.Files)),
+				bucket: b,
+			})
+		}
+		return items
+	}
+
+	switchKind := key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "mtime / atime"))
+	r := m.openReport(title(m), items(m), []key.Binding{m.keys.open, switchKind})
+	r.rebuild = func(m *Model) []list.Item {
+		m.report.list.Title = title(m)
+		return items(m)
+	}
+	r.handle = func(m *Model, msg tea.KeyMsg) (bool, tea.Cmd) {
+		switch {
+		case key.Matches(msg, switchKind):
+			if !useAccessTime && !m.Extended {
+				return true, m.reportStatus("Access times are only recorded in extended mode (-e)")
+			}
+			m.closeReport()
+			return true, m.showAges(!useAccessTime)
+
+		case key.Matches(msg, m.keys.open):
+			i, ok := m.report.list.SelectedItem().(ageItem)
+			if !ok {
+				return true, nil
+			}
+			m.showEntries(func(m *Model) string {
+				return fmt.Sprintf("Age by %s | %s", kind, i.bucket.Label)
+			}, func(m *Model) []Entry {
+				files := []Entry{}
+				for _, f := range i.bucket.Files {
+					if m.Tree.Contains(f.ID) {
+						files = append(files, f)
+					}
+				}
+				sort.SliceStable(files, func(a, b int) bool {
+					return m.size(files[a].Size, files[a].ApparentSize) > m.size(files[b].Size, files[b].ApparentSize)
+				})
+				return files
+			})
+			return true, nil
+		}
+		return false, nil
+	}
+	return nil
+}
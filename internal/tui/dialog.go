@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	. "github.com/davleop/godu/scan"
+)
+
+var (
+	dialogStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("25A065")).
+			Padding(1, 2)
+
+	dialogErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#E5484D", Dark: "#FF6369"})
+)
+
+// choice is one of the answers to a dialog.
+type choice struct {
+	binding key.Binding
+	action  func(m Model) (Model, tea.Cmd)
+}
+
+// dialog is a modal question shown on top of the browser. While it is open,
+// every key that isn't one of its choices is ignored.
+type dialog struct {
+	message string
+	choices []choice
+	err     error
+}
+
+func (d *dialog) update(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	for _, c := range d.choices {
+		if key.Matches(msg, c.binding) {
+			m.dialog = nil
+			return c.action(m)
+		}
+	}
+	return m, nil
+}
+
+func (d *dialog) view(width, height int) string {
+	var b strings.Builder
+	b.WriteString(d.message)
+	b.WriteString("\n\n")
+	answers := make([]string, 0, len(d.choices))
+	for _, c := range d.choices {
+		answers = append(answers, fmt.Sprintf("[%s] %s", c.binding.Help().Key, c.binding.Help().Desc))
+	}
+	b.WriteString(strings.Join(answers, "  "))
+	if d.err != nil {
+		b.WriteString("\n\n")
+		b.WriteString(dialogErrorStyle.Render(d.err.Error()))
+	}
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, dialogStyle.Render(b.String()))
+}
+
+// confirm asks a yes/no question before running action, which deletes or
+// otherwise changes files, unless --no-confirm-delete was given.
+func (m *Model) confirm(message string, action func(m *Model) tea.Cmd) tea.Cmd {
+	if !m.ConfirmDelete {
+		return action(m)
+	}
+	m.dialog = &dialog{
+		message: message,
+		choices: []choice{
+			{
+				binding: key.NewBinding(key.WithKeys("y", "Y"), key.WithHelp("y", "yes")),
+				action: func(m Model) (Model, tea.Cmd) {
+					cmd := action(&m)
+					return m, cmd
+				},
+			},
+			{
+				binding: key.NewBinding(key.WithKeys("n", "N", "esc", "q"), key.WithHelp("n", "no")),
+				action: func(m Model) (Model, tea.Cmd) {
+					return m, nil
+				},
+			},
+		},
+	}
+	return nil
+}
+
+// quitDialog asks whether the user really wants to quit, optionally writing
+// the tree to a file first so a long scan isn't lost.
+func (m Model) quitDialog() *dialog {
+	return &dialog{
+		message: "Really quit?",
+		choices: []choice{
+			{
+				binding: key.NewBinding(key.WithKeys("y", "Y", "ctrl+c"), key.WithHelp("y", "yes")),
+				action: func(m Model) (Model, tea.Cmd) {
+					return m, tea.Quit
+				},
+			},
+			{
+				binding: key.NewBinding(key.WithKeys("n", "N", "esc", "q"), key.WithHelp("n", "no")),
+				action: func(m Model) (Model, tea.Cmd) {
+					return m, nil
+				},
+			},
+			{
+				binding: key.NewBinding(key.WithKeys("e", "E"), key.WithHelp("e", "export and quit")),
+				action: func(m Model) (Model, tea.Cmd) {
+					path, err := m.export()
+					if err != nil {
+						m.dialog = m.quitDialog()
+						m.dialog.err = err
+						return m, nil
+					}
+					m.Exported = path
+					return m, tea.Quit
+				},
+			},
+		},
+	}
+}
+
+// export writes the whole tree to a timestamped file in the working
+// directory, so it can be browsed again with -f, and returns its path.
+func (m Model) export() (path string, err error) {
+	path, err = filepath.Abs(fmt.Sprintf("godu-%s.json", time.Now().Format("20060102-150405")))
+	if err != nil {
+		return "", err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("error setting output file: %w", err)
+	}
+	defer func() {
+		cerr := file.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+	return path, Export(file, m.Tree.Folder(m.Tree.Root()), "godu "+m.Version)
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"runtime"
+
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+type duplicatesMsg struct {
+	files  *FileList
+	groups []DuplicateGroup
+}
+
+type identicalFoldersMsg struct {
+	files *FileList
+}
+
+// findDuplicates hashes the files of the whole tree that could have a copy
+// in the background. The hashes are stored in the tree once they are in, so
+// opening the report again only reads the files it hasn't seen.
+func (m *Model) findDuplicates() tea.Cmd {
+	files := m.Tree.DuplicateCandidates(m.Tree.Root())
+	status := m.status("Searching for duplicate files...")
+	return tea.Batch(status, func() tea.Msg {
+		return duplicatesMsg{files, files.FindDuplicates(runtime.NumCPU())}
+	})
+}
+
+// findIdenticalFolders hashes the files of the whole tree like
+// findDuplicates. The folders are then compared by a hash of their content
+// once the file hashes are stored in the tree.
+func (m *Model) findIdenticalFolders() tea.Cmd {
+	files := m.Tree.DuplicateCandidates(m.Tree.Root())
+	status := m.status("Searching for identical folders...")
+	return tea.Batch(status, func() tea.Msg {
+		files.FindDuplicates(runtime.NumCPU())
+		return identicalFoldersMsg{files}
+	})
+}
+
+func (m *Model) showDuplicates(groups []DuplicateGroup) tea.Cmd {
+	d := &groupReport{name: "Duplicate files", noun: "duplicate files", canLink: true}
+	for _, g := range groups {
+		group := entryGroup{size: g.Size}
+		for _, f := range g.Files {
+			group.paths = append(group.paths, f.FullPath())
+		}
+		d.groups = append(d.groups, group)
+	}
+	return m.showGroups(d)
+}
+
+func (m *Model) showIdenticalFolders(groups []FolderGroup) tea.Cmd {
+	d := &groupReport{name: "Identical folders", noun: "identical folders"}
+	for _, g := range groups {
+		group := entryGroup{size: g.Size}
+		for _, f := range g.Folders {
+			group.paths = append(group.paths, f.Path)
+		}
+		d.groups = append(d.groups, group)
+	}
+	return m.showGroups(d)
+}
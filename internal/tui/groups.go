@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+// entryGroup is a set of files or folders with identical content.
+type entryGroup struct {
+	size  int64
+	paths []string
+}
+
+func (g entryGroup) wasted() int64 {
+	return g.size * int64(len(g.paths)-1)
+}
+
+// groupReport lists groups of identical entries, such as duplicate files,
+// and lets the user delete copies or replace them by hard links.
+type groupReport struct {
+	name   string
+	noun   string
+	groups []entryGroup
+	// canLink is set when the entries are files, which can be hard linked.
+	canLink bool
+}
+
+func (d *groupReport) items(m *Model) []list.Item {
+	items := []list.Item{}
+	for g, group := range d.groups {
+		items = append(items, reportItem{
+			title: fmt.Sprintf("%d copies of %s, %s wasted",
+				len(group.paths), m.formatSize(group.size), m.formatSize(group.wasted())),
+			group: g,
+		})
+		for _, path := range group.paths {
+			title := "    " + path
+			if !d.canLink {
+				title += string(filepath.Separator)
+			}
+			items = append(items, reportItem{
+				title: title,
+				path:  path,
+				isDir: !d.canLink,
+				group: g,
+			})
+		}
+	}
+	return items
+}
+
+func (d *groupReport) title(m *Model) string {
+	var wasted int64
+	for _, g := range d.groups {
+		wasted += g.wasted()
+	}
+	return fmt.Sprintf("%s | %d groups | %s wasted", d.name, len(d.groups), m.formatSize(wasted))
+}
+
+// drop removes path from its group, and the group once a single entry is
+// left in it.
+func (d *groupReport) drop(group int, path string) {
+	g := d.groups[group]
+	paths := make([]string, 0, len(g.paths))
+	for _, p := range g.paths {
+		if p != path {
+			paths = append(paths, p)
+		}
+	}
+	g.paths = paths
+
+	groups := make([]entryGroup, 0, len(d.groups))
+	groups = append(groups, d.groups[:group]...)
+	if len(g.paths) > 1 {
+		groups = append(groups, g)
+	}
+	d.groups = append(groups, d.groups[group+1:]...)
+}
+
+// identicalCopy returns another entry of the group that has exactly the same
+// content as path. The groups are made from hashes, which can collide, so the
+// content is compared again before a copy is deleted or linked.
+func (d *groupReport) identicalCopy(group int, path string) (string, error) {
+	for _, p := range d.groups[group].paths {
+		if p == path {
+			continue
+		}
+		if same, err := SameContent(p, path); err == nil && same {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no identical copy left, leaving it alone", path)
+}
+
+func (m *Model) showGroups(d *groupReport) tea.Cmd {
+	if len(d.groups) == 0 {
+		return m.status("No %s found", d.noun)
+	}
+
+	help := []key.Binding{m.keys.delete}
+	if d.canLink {
+		help = append(help, m.keys.link)
+	}
+	r := m.openReport(d.title(m), d.items(m), help)
+	r.rebuild = func(m *Model) []list.Item {
+		m.report.list.Title = d.title(m)
+		return d.items(m)
+	}
+	r.handle = func(m *Model, msg tea.KeyMsg) (bool, tea.Cmd) {
+		i, ok := m.report.list.SelectedItem().(reportItem)
+		switch {
+		case key.Matches(msg, m.keys.delete):
+			if !ok || i.path == "" {
+				return true, nil
+			}
+			if !m.EnableDelete {
+				return true, m.reportStatus("Deletion is disabled")
+			}
+			return true, m.confirm(fmt.Sprintf("Are you sure you want to delete %q?", i.path), func(m *Model) tea.Cmd {
+				if _, err := d.identicalCopy(i.group, i.path); err != nil {
+					return m.reportStatus("%v", err)
+				}
+				if err := m.deletePath(i.path); err != nil {
+					return m.reportStatus("%v", err)
+				}
+				d.drop(i.group, i.path)
+				m.changed()
+				return m.reportStatus("Deleted %s", i.path)
+			})
+
+		case d.canLink && key.Matches(msg, m.keys.link):
+			if !ok || i.path == "" {
+				return true, nil
+			}
+			if !m.EnableDelete {
+				return true, m.reportStatus("Deletion is disabled")
+			}
+			return true, m.confirm(fmt.Sprintf("Replace %q by a hard link to a copy?", i.path), func(m *Model) tea.Cmd {
+				// LinkDuplicate compares the content again, so the first
+				// copy that is still the same is used
+				var err error
+				for _, original := range d.groups[i.group].paths {
+					if original == i.path {
+						continue
+					}
+					if err = LinkDuplicate(original, i.path); err == nil {
+						d.drop(i.group, i.path)
+						m.report.list.SetItems(m.report.rebuild(m))
+						return m.reportStatus("Linked %s to %s", i.path, original)
+					}
+				}
+				return m.reportStatus("%v", err)
+			})
+		}
+		return false, nil
+	}
+	return nil
+}
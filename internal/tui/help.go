@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	helpTitleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("25A065"))
+
+	helpKeyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#909090", Dark: "#626262"})
+
+	helpColumnStyle = lipgloss.NewStyle().
+			MarginRight(4)
+)
+
+// helpView renders every key binding, grouped by category, full screen.
+func (m Model) helpView() string {
+	columns := []string{}
+	for _, group := range m.keys.groups() {
+		width := 0
+		for _, b := range group.bindings {
+			if w := lipgloss.Width(b.Help().Key); w > width {
+				width = w
+			}
+		}
+
+		lines := []string{helpTitleStyle.Render(group.name), ""}
+		for _, b := range group.bindings {
+			k := b.Help().Key
+			pad := strings.Repeat(" ", width-lipgloss.Width(k))
+			lines = append(lines, helpKeyStyle.Render(k)+pad+"  "+b.Help().Desc)
+		}
+		columns = append(columns, helpColumnStyle.Render(strings.Join(lines, "\n")))
+	}
+
+	// put as many groups next to each other as fit on the screen
+	rows := []string{}
+	row := []string{}
+	for _, column := range columns {
+		if len(row) > 0 && lipgloss.Width(lipgloss.JoinHorizontal(lipgloss.Top, append(row, column)...)) > m.width {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row = []string{}
+		}
+		row = append(row, column)
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	body := strings.Join(rows, "\n\n")
+	title := titleStyle.Render("godu-"+m.Version+" | Help") + "\n\n"
+	footer := "\n\n" + helpKeyStyle.Render("press "+m.keys.help.Help().Key+" or esc to close")
+	return lipgloss.Place(m.width, m.height, lipgloss.Left, lipgloss.Top, title+body+footer)
+}
@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// keyMap holds every key binding of the browser. The bindings follow ncdu
+// where it has an equivalent.
+type keyMap struct {
+	// navigation
+	up       key.Binding
+	down     key.Binding
+	pageUp   key.Binding
+	pageDown key.Binding
+	top      key.Binding
+	bottom   key.Binding
+	open     key.Binding
+	parent   key.Binding
+
+	// sorting
+	sortName      key.Binding
+	sortSize      key.Binding
+	sortItemCount key.Binding
+	sortMTime     key.Binding
+
+	// display toggles
+	toggleApparent  key.Binding
+	toggleItemCount key.Binding
+	toggleMTime     key.Binding
+	toggleGraph     key.Binding
+	toggleHidden    key.Binding
+	toggleDirsFirst key.Binding
+
+	// marking
+	mark       key.Binding
+	viewMarked key.Binding
+
+	// actions
+	delete  key.Binding
+	shell   key.Binding
+	refresh key.Binding
+	info    key.Binding
+	link    key.Binding
+	help    key.Binding
+	quit    key.Binding
+
+	// views
+	search  key.Binding
+	treemap key.Binding
+
+	// reports
+	largest          key.Binding
+	fileTypes        key.Binding
+	ages             key.Binding
+	owners           key.Binding
+	duplicates       key.Binding
+	identicalFolders key.Binding
+}
+
+func binding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(
+		key.WithKeys(keys...),
+		key.WithHelp(strings.Join(keys, "/"), desc),
+	)
+}
+
+func newKeyMap() *keyMap {
+	return &keyMap{
+		up:       binding("up", "up", "k"),
+		down:     binding("down", "down", "j"),
+		pageUp:   binding("previous page", "pgup"),
+		pageDown: binding("next page", "pgdown"),
+		top:      binding("go to top", "home"),
+		bottom:   binding("go to bottom", "end", "G"),
+		open:     binding("open directory", "enter", "right", "l"),
+		parent:   binding("parent directory", "left", "h", "<", "backspace"),
+
+		sortName:      binding("sort by name", "n"),
+		sortSize:      binding("sort by size", "s"),
+		sortItemCount: binding("sort by items", "C"),
+		sortMTime:     binding("sort by mtime", "M"),
+
+		toggleApparent:  binding("apparent size / disk usage", "a"),
+		toggleItemCount: binding("show item counts", "c"),
+		toggleMTime:     binding("show mtime", "m"),
+		toggleGraph:     binding("cycle graph / percent", "g"),
+		toggleHidden:    binding("show hidden / excluded", "e"),
+		toggleDirsFirst: binding("directories first", "t"),
+
+		mark:       key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "mark / unmark")),
+		viewMarked: binding("view marked", "v"),
+
+		delete:  binding("delete", "d"),
+		shell:   binding("spawn shell", "b"),
+		refresh: binding("refresh directory", "r"),
+		info:    binding("show info", "i"),
+		link:    binding("hard link duplicate", "L"),
+		help:    binding("help", "?"),
+		quit:    binding("quit", "q"),
+
+		search:  binding("search tree", "f"),
+		treemap: binding("treemap", "V"),
+
+		largest:          binding("largest files", "T"),
+		fileTypes:        binding("file types", "E"),
+		ages:             binding("age histogram", "A"),
+		owners:           binding("owners", "O"),
+		duplicates:       binding("duplicate files", "D"),
+		identicalFolders: binding("identical folders", "F"),
+	}
+}
+
+// actions returns the bindings by the name they are configured with.
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":                &k.up,
+		"down":              &k.down,
+		"page-up":           &k.pageUp,
+		"page-down":         &k.pageDown,
+		"top":               &k.top,
+		"bottom":            &k.bottom,
+		"open":              &k.open,
+		"parent":            &k.parent,
+		"sort-name":         &k.sortName,
+		"sort-size":         &k.sortSize,
+		"sort-itemcount":    &k.sortItemCount,
+		"sort-mtime":        &k.sortMTime,
+		"toggle-apparent":   &k.toggleApparent,
+		"toggle-itemcount":  &k.toggleItemCount,
+		"toggle-mtime":      &k.toggleMTime,
+		"toggle-graph":      &k.toggleGraph,
+		"toggle-hidden":     &k.toggleHidden,
+		"toggle-dirs-first": &k.toggleDirsFirst,
+		"mark":              &k.mark,
+		"view-marked":       &k.viewMarked,
+		"delete":            &k.delete,
+		"shell":             &k.shell,
+		"refresh":           &k.refresh,
+		"info":              &k.info,
+		"link":              &k.link,
+		"help":              &k.help,
+		"quit":              &k.quit,
+		"search":            &k.search,
+		"treemap":           &k.treemap,
+		"largest":           &k.largest,
+		"file-types":        &k.fileTypes,
+		"ages":              &k.ages,
+		"owners":            &k.owners,
+		"duplicates":        &k.duplicates,
+		"identical-folders": &k.identicalFolders,
+	}
+}
+
+// rebind replaces the keys of the bindings named in bindings.
+func (k *keyMap) rebind(bindings map[string][]string) error {
+	actions := k.actions()
+	for action, keys := range bindings {
+		b, ok := actions[action]
+		if !ok {
+			return fmt.Errorf("unknown action %q", action)
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	}
+	return nil
+}
+
+// apply hands the navigation bindings to the list, and takes away the
+// list's own bindings that would clash with godu's.
+func (k *keyMap) apply(l *list.Model) {
+	l.KeyMap.CursorUp = k.up
+	l.KeyMap.CursorDown = k.down
+	l.KeyMap.PrevPage = k.pageUp
+	l.KeyMap.NextPage = k.pageDown
+	l.KeyMap.GoToStart = k.top
+	l.KeyMap.GoToEnd = k.bottom
+	l.KeyMap.Quit = k.quit
+	l.KeyMap.ShowFullHelp.Unbind()
+	l.KeyMap.CloseFullHelp.Unbind()
+}
+
+// ShortHelp is shown below the list.
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.open, k.parent, k.delete, k.help, k.quit}
+}
+
+type keyGroup struct {
+	name     string
+	bindings []key.Binding
+}
+
+// groups returns the bindings as they are listed in the help screen.
+func (k keyMap) groups() []keyGroup {
+	return []keyGroup{
+		{"Navigation", []key.Binding{k.up, k.down, k.pageUp, k.pageDown, k.top, k.bottom, k.open, k.parent}},
+		{"Sorting", []key.Binding{k.sortName, k.sortSize, k.sortItemCount, k.sortMTime}},
+		{"Display", []key.Binding{k.toggleApparent, k.toggleItemCount, k.toggleMTime, k.toggleGraph, k.toggleHidden, k.toggleDirsFirst}},
+		{"Marking", []key.Binding{k.mark, k.viewMarked}},
+		{"Actions", []key.Binding{k.delete, k.link, k.shell, k.refresh, k.info, k.help, k.quit}},
+		{"Views", []key.Binding{k.search, k.treemap}},
+		{"Reports", []key.Binding{k.largest, k.fileTypes, k.ages, k.owners, k.duplicates, k.identicalFolders}},
+	}
+}
+
+// Actions returns the names of all actions that can be rebound.
+func Actions() []string {
+	names := []string{}
+	for name := range newKeyMap().actions() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseBinding parses a --bind value of the form ACTION=KEY[,KEY...].
+func ParseBinding(s string) (string, []string, error) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return "", nil, fmt.Errorf("expected ACTION=KEY[,KEY...], got %q", s)
+	}
+	action, keys := s[:i], strings.Split(s[i+1:], ",")
+	if _, ok := newKeyMap().actions()[action]; !ok {
+		return "", nil, fmt.Errorf("unknown action %q, expected one of %s", action, strings.Join(Actions(), ", "))
+	}
+	for _, k := range keys {
+		if k == "" {
+			return "", nil, fmt.Errorf("empty key in binding %q", s)
+		}
+	}
+	return action, keys, nil
+}
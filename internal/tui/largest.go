@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+// largestCount is how many files the largest files report lists.
+const largestCount = 100
+
+// showLargest opens a flat list of the largest files below the root.
+func (m *Model) showLargest() tea.Cmd {
+	m.showEntries(func(m *Model) string {
+		return fmt.Sprintf("Largest files | %s", m.Tree.Path(m.Tree.Root()))
+	}, func(m *Model) []Entry {
+		return m.Tree.Largest(m.Tree.Root(), largestCount, false, m.UseApparentSize)
+	})
+	return nil
+}
+
+// showEntries opens a report listing the entries with their full path. open
+// shows an entry in its folder and delete removes it. The report calls
+// entries again whenever the tree changes. help replaces the default help
+// line, for callers adding their own actions.
+func (m *Model) showEntries(title func(m *Model) string, entries func(m *Model) []Entry, help ...key.Binding) *report {
+	items := func(m *Model) []list.Item {
+		items := []list.Item{}
+		for _, e := range entries(m) {
+			path := e.Path
+			if e.IsDir {
+				path += string(filepath.Separator)
+			}
+			items = append(items, reportItem{
+				title: fmt.Sprintf("%-2s%8s  %s", m.markColumn(e.Path), m.formatSize(m.size(e.Size, e.ApparentSize)), path),
+				path:  e.Path,
+				isDir: e.IsDir,
+			})
+		}
+		return items
+	}
+
+	if len(help) == 0 {
+		help = []key.Binding{m.keys.open, m.keys.delete}
+	}
+	r := m.openReport(title(m), items(m), help)
+	r.rebuild = func(m *Model) []list.Item {
+		m.report.list.Title = title(m)
+		return items(m)
+	}
+	r.handle = func(m *Model, msg tea.KeyMsg) (bool, tea.Cmd) {
+		i, ok := m.report.list.SelectedItem().(reportItem)
+		if !ok {
+			return false, nil
+		}
+		switch {
+		case key.Matches(msg, m.keys.delete):
+			if !m.EnableDelete {
+				return true, m.reportStatus("Deletion is disabled")
+			}
+			return true, m.confirm(fmt.Sprintf("Are you sure you want to delete %q?", i.path), func(m *Model) tea.Cmd {
+				if err := m.deletePath(i.path); err != nil {
+					return m.reportStatus("%v", err)
+				}
+				m.changed()
+				return m.reportStatus("Deleted %s", i.path)
+			})
+
+		case key.Matches(msg, m.keys.open):
+			// show the entry in its folder
+			m.report = nil
+			m.goTo(filepath.Dir(i.path))
+			m.selectName(filepath.Base(i.path))
+			return true, nil
+		}
+		return false, nil
+	}
+	return r
+}
@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+// Marks are kept by full path, so they survive moving between folders and
+// changes to the tree. Entries that disappear from the tree are unmarked.
+
+// toggleMark marks or unmarks the selected entry and moves on to the next
+// one, so a run of entries can be marked by holding the key.
+func (m *Model) toggleMark() {
+	i, ok := m.list.SelectedItem().(item)
+	if !ok || i.name == ".." {
+		return
+	}
+	path := filepath.Join(m.Tree.Path(m.Current), i.name)
+	if m.marked[path] {
+		delete(m.marked, path)
+	} else {
+		m.marked[path] = true
+	}
+	index := m.list.Index()
+	m.refresh()
+	m.list.Select(index)
+	m.list.CursorDown()
+}
+
+// markColumn returns the marker shown in front of the entry at path.
+func (m Model) markColumn(path string) string {
+	if m.marked[path] {
+		return "*"
+	}
+	return ""
+}
+
+// pruneMarks unmarks the entries that are no longer in the tree.
+func (m *Model) pruneMarks() {
+	for path := range m.marked {
+		if !m.exists(path) {
+			delete(m.marked, path)
+		}
+	}
+}
+
+// markedRoots returns the marked entries that aren't inside another marked
+// folder, sorted by path. Acting on those covers everything that is marked.
+func (m *Model) markedRoots() []Entry {
+	paths := make([]string, 0, len(m.marked))
+	for path := range m.marked {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	entries := []Entry{}
+	for _, path := range paths {
+		if n := len(entries); n > 0 && entries[n-1].IsDir &&
+			strings.HasPrefix(path, entries[n-1].Path+string(filepath.Separator)) {
+			continue
+		}
+		if e, ok := m.lookup(path); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// markedSize is the total size of the marked entries, counting entries
+// inside marked folders once.
+func (m *Model) markedSize() int64 {
+	var size int64
+	for _, e := range m.markedRoots() {
+		size += m.size(e.Size, e.ApparentSize)
+	}
+	return size
+}
+
+// showMarked opens the list of marked entries, from which they can be
+// deleted, exported or copied all at once.
+func (m *Model) showMarked() tea.Cmd {
+	if len(m.marked) == 0 {
+		return m.status("Nothing is marked, mark entries with %s", m.keys.mark.Help().Key)
+	}
+
+	deleteAll := key.NewBinding(key.WithKeys(m.keys.delete.Keys()...), key.WithHelp(m.keys.delete.Help().Key, "delete all"))
+	export := binding("export", "e")
+	copyPaths := binding("copy paths", "y")
+	unmarkAll := binding("unmark all", "x")
+	unmark := key.NewBinding(key.WithKeys(m.keys.mark.Keys()...), key.WithHelp(m.keys.mark.Help().Key, "unmark"))
+
+	r := m.showEntries(func(m *Model) string {
+		return fmt.Sprintf("Marked | %d entries | %s", len(m.marked), m.formatSize(m.markedSize()))
+	}, func(m *Model) []Entry {
+		entries := []Entry{}
+		for path := range m.marked {
+			if e, ok := m.lookup(path); ok {
+				entries = append(entries, e)
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+		return entries
+	}, m.keys.open, unmark, deleteAll, export, copyPaths, unmarkAll)
+
+	entries := r.handle
+	r.handle = func(m *Model, msg tea.KeyMsg) (bool, tea.Cmd) {
+		switch {
+		case key.Matches(msg, unmark):
+			if i, ok := m.report.list.SelectedItem().(reportItem); ok {
+				delete(m.marked, i.path)
+				m.closeMarkedIfEmpty()
+			}
+			return true, nil
+		case key.Matches(msg, unmarkAll):
+			m.marked = map[string]bool{}
+			m.closeMarkedIfEmpty()
+			return true, nil
+		case key.Matches(msg, deleteAll):
+			return true, m.deleteMarked()
+		case key.Matches(msg, export):
+			return true, m.exportMarked()
+		case key.Matches(msg, copyPaths):
+			return true, m.copyMarked()
+		}
+		return entries(m, msg)
+	}
+	return nil
+}
+
+// closeMarkedIfEmpty updates the marked view after entries were unmarked,
+// going back to the browser once nothing is left.
+func (m *Model) closeMarkedIfEmpty() {
+	if len(m.marked) == 0 {
+		m.report = nil
+	} else {
+		m.report.list.SetItems(m.report.rebuild(m))
+	}
+	m.refresh()
+}
+
+// deleteMarked deletes every marked entry after asking once.
+func (m *Model) deleteMarked() tea.Cmd {
+	if !m.EnableDelete {
+		return m.notify("Deletion is disabled")
+	}
+	entries := m.markedRoots()
+	message := fmt.Sprintf("Are you sure you want to delete %d marked entries (%s)?", len(entries), m.formatSize(m.markedSize()))
+	return m.confirm(message, func(m *Model) tea.Cmd {
+		var errs []string
+		for _, e := range entries {
+			if err := m.deletePath(e.Path); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		m.changed()
+		if m.report != nil && len(m.marked) == 0 {
+			m.report = nil
+		}
+		if len(errs) > 0 {
+			return m.notify("Deleted %d of %d entries: %s", len(entries)-len(errs), len(entries), errs[0])
+		}
+		return m.notify("Deleted %d entries", len(entries))
+	})
+}
+
+// exportMarked writes the marked entries to a JSON file in the current
+// directory, in the same format as godu top --json.
+func (m *Model) exportMarked() tea.Cmd {
+	name := fmt.Sprintf("godu-marked-%s.json", time.Now().Format("20060102-150405"))
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return m.notify("error setting output file: %v", err)
+	}
+	entries := m.markedRoots()
+	err = json.NewEncoder(file).Encode(entries)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return m.notify("%v", err)
+	}
+	return m.notify("Exported %d entries to %s", len(entries), name)
+}
+
+// copyMarked puts the paths of the marked entries on the clipboard, one per
+// line, using the OSC 52 escape sequence so it also works over ssh.
+func (m *Model) copyMarked() tea.Cmd {
+	paths := make([]string, 0, len(m.marked))
+	for path := range m.marked {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	n := len(paths)
+	return tea.Exec(&clipboardCommand{text: strings.Join(paths, "\n")}, func(err error) tea.Msg {
+		return clipboardMsg{paths: n, err: err}
+	})
+}
+
+// clipboardMsg tells how copying paths to the clipboard went.
+type clipboardMsg struct {
+	paths int
+	err   error
+}
+
+// clipboardCommand writes text to the clipboard with OSC 52. It is run with
+// tea.Exec, which hands it the program's output while nothing is being
+// drawn, so that the sequence can't end up in the middle of a frame.
+type clipboardCommand struct {
+	text string
+	out  io.Writer
+}
+
+func (c *clipboardCommand) Run() error {
+	_, err := fmt.Fprintf(c.out, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(c.text)))
+	return err
+}
+
+func (c *clipboardCommand) SetStdin(io.Reader)    {}
+func (c *clipboardCommand) SetStdout(w io.Writer) { c.out = w }
+func (c *clipboardCommand) SetStderr(io.Writer)   {}
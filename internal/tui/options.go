@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+type GraphStyle int64
+
+const (
+	Hash GraphStyle = iota
+	HalfBlock
+	EighthBlock
+)
+
+func (g GraphStyle) String() string {
+	switch g {
+	case Hash:
+		return "hash"
+	case HalfBlock:
+		return "half-block"
+	case EighthBlock:
+		return "eighth-block"
+	}
+	return "unknown"
+}
+
+// ParseGraphStyle parses a --graph-style value.
+func ParseGraphStyle(s string) (GraphStyle, error) {
+	for _, g := range []GraphStyle{Hash, HalfBlock, EighthBlock} {
+		if s == g.String() {
+			return g, nil
+		}
+	}
+	return Hash, fmt.Errorf("unknown graph style %q, expected hash, half-block or eighth-block", s)
+}
+
+type ColorScheme int64
+
+const (
+	ColorOff ColorScheme = iota
+	ColorDark
+	ColorDarkBg
+)
+
+func (c ColorScheme) String() string {
+	switch c {
+	case ColorOff:
+		return "off"
+	case ColorDark:
+		return "dark"
+	case ColorDarkBg:
+		return "dark-bg"
+	}
+	return "unknown"
+}
+
+// ParseColorScheme parses a --color value.
+func ParseColorScheme(s string) (ColorScheme, error) {
+	for _, c := range []ColorScheme{ColorOff, ColorDark, ColorDarkBg} {
+		if s == c.String() {
+			return c, nil
+		}
+	}
+	return ColorOff, fmt.Errorf("unknown color scheme %q, expected off, dark or dark-bg", s)
+}
+
+type SharedColumn int64
+
+const (
+	SharedOff SharedColumn = iota
+	Shared
+	Unique
+)
+
+func (s SharedColumn) String() string {
+	switch s {
+	case SharedOff:
+		return "off"
+	case Shared:
+		return "shared"
+	case Unique:
+		return "unique"
+	}
+	return "unknown"
+}
+
+// ParseSharedColumn parses a --shared-column value.
+func ParseSharedColumn(s string) (SharedColumn, error) {
+	for _, c := range []SharedColumn{SharedOff, Shared, Unique} {
+		if s == c.String() {
+			return c, nil
+		}
+	}
+	return SharedOff, fmt.Errorf("unknown shared column %q, expected off, shared or unique", s)
+}
+
+// ParseSort parses a --sort value such as "name" or "disk-usage-asc" into the
+// column to sort on and whether the order is descending. Without a suffix,
+// names sort ascending and everything else descending, like ncdu.
+func ParseSort(s string) (Order, bool, error) {
+	column, desc, suffix := s, true, false
+	if strings.HasSuffix(s, "-asc") {
+		column, desc, suffix = strings.TrimSuffix(s, "-asc"), false, true
+	} else if strings.HasSuffix(s, "-desc") {
+		column, desc, suffix = strings.TrimSuffix(s, "-desc"), true, true
+	}
+
+	var order Order
+	switch column {
+	case "disk-usage":
+		order = Size
+	case "name":
+		order = Name
+		if !suffix {
+			desc = false
+		}
+	case "apparent-size":
+		order = ApparentSize
+	case "itemcount":
+		order = ItemCount
+	case "mtime":
+		order = ModTime
+	default:
+		return Undefined, false, fmt.Errorf("unknown sort column %q, expected disk-usage, name, apparent-size, itemcount or mtime", column)
+	}
+	return order, desc, nil
+}
+
+// Options holds everything about the browser that can be set from the
+// command line.
+type Options struct {
+	ListOrder      Order
+	Descending     bool
+	DirectoryFirst bool
+
+	Extended        bool
+	SI              bool
+	UseApparentSize bool
+	ShowHidden      bool
+	ShowItemCount   bool
+	ShowMTime       bool
+	ShowGraph       bool
+	ShowPercent     bool
+	GraphStyle      GraphStyle
+	SharedColumn    SharedColumn
+	Color           ColorScheme
+
+	EnableShell   bool
+	EnableDelete  bool
+	EnableRefresh bool
+	ConfirmQuit   bool
+	ConfirmDelete bool
+
+	// Watch keeps the tree up to date with the changes made on disk while
+	// browsing, where the system supports it.
+	Watch bool
+
+	// KeyBindings replaces the keys of the actions named, see Actions.
+	KeyBindings map[string][]string
+}
+
+// DefaultOptions returns the options godu uses when nothing else is given.
+func DefaultOptions() Options {
+	return Options{
+		ListOrder:      Size,
+		Descending:     true,
+		DirectoryFirst: true,
+		ShowHidden:     true,
+		ShowGraph:      true,
+		ShowPercent:    true,
+		GraphStyle:     Hash,
+		SharedColumn:   Shared,
+		Color:          ColorDarkBg,
+		EnableShell:    true,
+		EnableDelete:   true,
+		EnableRefresh:  true,
+		ConfirmQuit:    true,
+		ConfirmDelete:  true,
+	}
+}
@@ -0,0 +1,26 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showOwners opens the breakdown of the current folder by the user owning
+// the files, or by their group. The tab key switches between the two.
+func (m *Model) showOwners(byGroup bool) tea.Cmd {
+	b := breakdown{
+		kind:     "user",
+		groups:   m.Tree.ByUser(m.Current),
+		name:     func(name string) string { return name },
+		switchTo: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "user / group")),
+		switchFn: func(m *Model) tea.Cmd {
+			m.closeReport()
+			return m.showOwners(!byGroup)
+		},
+	}
+	if byGroup {
+		b.kind, b.groups = "group", m.Tree.ByGroup(m.Current)
+	}
+	m.showBreakdown(b)
+	return nil
+}
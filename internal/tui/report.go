@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+// report is a full-screen list shown instead of the browser, such as the
+// duplicate finder, until it is closed with esc or the quit key.
+type report struct {
+	list list.Model
+	// handle runs the report's own actions. Keys it doesn't handle go to
+	// the list.
+	handle func(m *Model, msg tea.KeyMsg) (bool, tea.Cmd)
+	// rebuild recreates the items after the tree changed.
+	rebuild func(m *Model) []list.Item
+	// parent is the report this one was opened from, which is shown again
+	// when this one is closed.
+	parent *report
+}
+
+// reportItem is an entry in a report. path is empty for lines that don't
+// stand for an entry in the tree, e.g. headings.
+type reportItem struct {
+	title string
+	path  string
+	isDir bool
+	group int
+}
+
+func (i reportItem) Title() string       { return i.title }
+func (i reportItem) Description() string { return "" }
+func (i reportItem) FilterValue() string { return i.path }
+
+// openReport shows a report with the given title and items. help lists the
+// report's own key bindings.
+func (m *Model) openReport(title string, items []list.Item, help []key.Binding) *report {
+	closeKey := key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc/"+m.keys.quit.Help().Key, "close"))
+	help = append(help, closeKey)
+
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
+	delegate.SetHeight(1)
+	delegate.ShortHelpFunc = func() []key.Binding {
+		return help
+	}
+	delegate.FullHelpFunc = func() [][]key.Binding {
+		return [][]key.Binding{help}
+	}
+
+	l := list.New(items, delegate, m.width, m.height)
+	l.Title = title
+	l.Styles.Title = titleStyle
+	m.keys.apply(&l)
+	l.DisableQuitKeybindings()
+
+	m.report = &report{list: l, parent: m.report}
+	return m.report
+}
+
+// updateReport passes msg to the open report.
+func (m Model) updateReport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := m.report
+	if r.list.FilterState() != list.Filtering {
+		if msg.Type == tea.KeyEsc || key.Matches(msg, m.keys.quit) {
+			m.closeReport()
+			return m, nil
+		}
+		if r.handle != nil {
+			if handled, cmd := r.handle(&m, msg); handled {
+				return m, cmd
+			}
+		}
+	}
+	var cmd tea.Cmd
+	r.list, cmd = r.list.Update(msg)
+	return m, cmd
+}
+
+// closeReport goes back to the report the open one was opened from, or to
+// the browser.
+func (m *Model) closeReport() {
+	m.report = m.report.parent
+	if r := m.report; r != nil {
+		r.list.SetSize(m.width, m.height)
+		if r.rebuild != nil {
+			r.list.SetItems(r.rebuild(m))
+		}
+	}
+}
+
+// reportStatus shows a status message in the open report.
+func (m *Model) reportStatus(format string, a ...interface{}) tea.Cmd {
+	return m.report.list.NewStatusMessage(statusMessageStyle(fmt.Sprintf(format, a...)))
+}
+
+// changed updates the browser and the open report after entries were
+// deleted from the tree, e.g. from a report. If the current folder is gone,
+// the deepest of its parents still in the tree becomes the current one.
+func (m *Model) changed() {
+	m.pruneMarks()
+	m.goTo(m.Tree.Path(m.Current))
+	if m.report != nil && m.report.rebuild != nil {
+		m.report.list.SetItems(m.report.rebuild(m))
+	}
+}
+
+// deletePath deletes the entry at path from disk and from the tree.
+func (m *Model) deletePath(path string) error {
+	id, ok := m.Tree.Lookup(path)
+	if !ok {
+		return fmt.Errorf("error deleting %s: not found in %s", path, m.Tree.Path(m.Tree.Root()))
+	}
+	return m.Tree.Delete(id)
+}
+
+// goTo makes the folder at path the current one, or the deepest of its
+// parents that is still in the tree.
+func (m *Model) goTo(path string) {
+	t := m.Tree
+	m.Current = t.Root()
+	if rel, err := filepath.Rel(t.Path(m.Current), path); err == nil && rel != "." {
+		for _, name := range strings.Split(rel, string(filepath.Separator)) {
+			id, ok := t.Child(m.Current, name)
+			if !ok || !t.IsDir(id) {
+				break
+			}
+			m.Current = id
+		}
+	}
+	m.refresh()
+}
+
+// exists reports whether the entry at path is still in the tree.
+func (m *Model) exists(path string) bool {
+	_, ok := m.Tree.Lookup(path)
+	return ok
+}
+
+// lookup finds the entry at path in the tree.
+func (m *Model) lookup(path string) (Entry, bool) {
+	id, ok := m.Tree.Lookup(path)
+	if !ok {
+		return Entry{}, false
+	}
+	return m.Tree.Entry(id), true
+}
+
+// notify shows a status message in the open report, or in the browser.
+func (m *Model) notify(format string, a ...interface{}) tea.Cmd {
+	if m.report != nil {
+		return m.reportStatus(format, a...)
+	}
+	return m.status(format, a...)
+}
+
+// selectName moves the cursor to the entry called name in the current
+// folder.
+func (m *Model) selectName(name string) {
+	for i, it := range m.list.Items() {
+		if it.(item).name == name {
+			m.list.Select(i)
+			return
+		}
+	}
+}
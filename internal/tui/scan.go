@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	. "github.com/davleop/godu/scan"
+)
+
+// ErrScanAborted is returned by Scan when the user quits before the scan is
+// finished.
+var ErrScanAborted = errors.New("scan aborted")
+
+var scanBoxStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	Padding(0, 1)
+
+type scanTickMsg time.Time
+
+type scanDoneMsg struct {
+	tree *Tree
+	err  error
+}
+
+// scanModel shows the progress of a running scan. Progress is only sampled
+// on every tick, so however many entries are scanned in between, the
+// terminal sees at most one update per interval.
+type scanModel struct {
+	scan     func() (*Tree, error)
+	progress *Progress
+	interval time.Duration
+	start    time.Time
+	abort    key.Binding
+
+	// sampled on every tick
+	items   int64
+	size    int64
+	errors  int64
+	path    string
+	elapsed time.Duration
+
+	tree *Tree
+	err  error
+}
+
+// Scan runs scan while showing the progress it reports through progress
+// full screen, refreshing every interval.
+func Scan(scan func() (*Tree, error), progress *Progress, interval time.Duration) (*Tree, error) {
+	m := scanModel{
+		scan:     scan,
+		progress: progress,
+		interval: interval,
+		start:    time.Now(),
+		abort: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "abort"),
+		),
+	}
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).StartReturningModel()
+	if err != nil {
+		return nil, err
+	}
+	m = final.(scanModel)
+	return m.tree, m.err
+}
+
+func (m scanModel) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(t time.Time) tea.Msg {
+		return scanTickMsg(t)
+	})
+}
+
+func (m scanModel) runScan() tea.Msg {
+	tree, err := m.scan()
+	return scanDoneMsg{tree: tree, err: err}
+}
+
+func (m scanModel) Init() tea.Cmd {
+	return tea.Batch(m.runScan, m.tick())
+}
+
+func (m scanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case scanTickMsg:
+		m.items = m.progress.Items()
+		m.size = m.progress.Size()
+		m.errors = m.progress.Errors()
+		m.path = m.progress.Path()
+		m.elapsed = time.Since(m.start).Truncate(time.Second)
+		return m, m.tick()
+
+	case scanDoneMsg:
+		m.tree, m.err = msg.tree, msg.err
+		return m, tea.Quit
+
+	case tea.KeyMsg:
+		if key.Matches(msg, m.abort) {
+			m.err = ErrScanAborted
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m scanModel) View() string {
+	body := fmt.Sprintf(
+		"Total items: %-10d size: %s\nCurrent item: %s\n\nElapsed: %s",
+		m.items, PrettyPrintSize(m.size), m.path, m.elapsed)
+	if m.errors > 0 {
+		body += fmt.Sprintf("\nWarning: error reading %d directories", m.errors)
+	}
+	body += "\n\nPress " + m.abort.Help().Key + " to " + m.abort.Help().Desc
+	return appStyle.Render(scanBoxStyle.Render(body))
+}
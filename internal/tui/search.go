@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	. "github.com/davleop/godu/scan"
+)
+
+// searchPrompt asks for a query to search the whole tree with.
+type searchPrompt struct {
+	input    textinput.Model
+	mode     SearchMode
+	fullPath bool
+	err      error
+	keys     searchKeys
+}
+
+type searchKeys struct {
+	run        key.Binding
+	cancel     key.Binding
+	mode       key.Binding
+	togglePath key.Binding
+}
+
+func (m *Model) openSearch() tea.Cmd {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "pattern"
+	input.Width = 40
+	s := &searchPrompt{
+		input: input,
+		keys: searchKeys{
+			run:        binding("search", "enter"),
+			cancel:     binding("cancel", "esc"),
+			mode:       binding("substring / glob / regexp", "tab"),
+			togglePath: binding("name / full path", "ctrl+p"),
+		},
+	}
+	// start from the previous search
+	if m.search != nil {
+		s.input.SetValue(m.search.input.Value())
+		s.mode, s.fullPath = m.search.mode, m.search.fullPath
+	}
+	m.search = s
+	m.searching = true
+	return s.input.Focus()
+}
+
+// updateSearch handles the keys of the search prompt.
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.search
+	switch {
+	case key.Matches(msg, s.keys.cancel):
+		m.searching = false
+		return m, nil
+	case key.Matches(msg, s.keys.mode):
+		s.mode = (s.mode + 1) % (Regexp + 1)
+		return m, nil
+	case key.Matches(msg, s.keys.togglePath):
+		s.fullPath = !s.fullPath
+		return m, nil
+	case key.Matches(msg, s.keys.run):
+		q := Query{Pattern: s.input.Value(), Mode: s.mode, FullPath: s.fullPath}
+		if q.Pattern == "" {
+			return m, nil
+		}
+		entries, err := m.Tree.Search(m.Tree.Root(), q)
+		if err != nil {
+			s.err = err
+			return m, nil
+		}
+		s.err = nil
+		m.searching = false
+		return m, m.showSearchResults(q, entries)
+	}
+
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return m, cmd
+}
+
+// showSearchResults lists what a search found, largest first. Opening a
+// result shows it in its folder. The results keep the IDs they were found
+// with, so they don't have to be looked up again after every change.
+func (m *Model) showSearchResults(q Query, found []Entry) tea.Cmd {
+	m.report = nil
+	m.showEntries(func(m *Model) string {
+		n := 0
+		for _, e := range found {
+			if m.Tree.Contains(e.ID) {
+				n++
+			}
+		}
+		return fmt.Sprintf("Search: %s (%s) | %d results", q.Pattern, q.Mode, n)
+	}, func(m *Model) []Entry {
+		entries := make([]Entry, 0, len(found))
+		for _, e := range found {
+			if m.Tree.Contains(e.ID) {
+				entries = append(entries, m.Tree.Entry(e.ID))
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return m.size(entries[i].Size, entries[i].ApparentSize) > m.size(entries[j].Size, entries[j].ApparentSize)
+		})
+		return entries
+	})
+	if len(found) == 0 {
+		return m.reportStatus("Nothing found")
+	}
+	return nil
+}
+
+// searchView draws the search prompt in the middle of the screen.
+func (m Model) searchView() string {
+	s := m.search
+	var b strings.Builder
+	scope := "names"
+	if s.fullPath {
+		scope = "full paths"
+	}
+	fmt.Fprintf(&b, "Search %s by %s\n\n", scope, s.mode)
+	b.WriteString(s.input.View())
+	b.WriteString("\n\n")
+	help := []key.Binding{s.keys.run, s.keys.mode, s.keys.togglePath, s.keys.cancel}
+	parts := make([]string, 0, len(help))
+	for _, h := range help {
+		parts = append(parts, h.Help().Key+" "+h.Help().Desc)
+	}
+	b.WriteString(lipgloss.NewStyle().Faint(true).Render(strings.Join(parts, " • ")))
+	if s.err != nil {
+		b.WriteString("\n\n")
+		b.WriteString(dialogErrorStyle.Render(s.err.Error()))
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialogStyle.Render(b.String()))
+}
@@ -0,0 +1,387 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// treemapColors are the backgrounds the rectangles cycle through.
+var treemapColors = []lipgloss.AdaptiveColor{
+	{Light: "#A8D5BA", Dark: "#1F5F3F"},
+	{Light: "#A9C5E8", Dark: "#24476B"},
+	{Light: "#E8C9A9", Dark: "#6B4A24"},
+	{Light: "#D3B5E0", Dark: "#553366"},
+	{Light: "#E8E3A9", Dark: "#5E5A1F"},
+	{Light: "#E0B5B5", Dark: "#663333"},
+}
+
+var treemapSelectedStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("FFFDF5")).
+	Background(lipgloss.Color("25A065")).
+	Bold(true)
+
+// treemap is the state of the treemap view of the current folder. The
+// layout itself is computed from the folder every time, so it always
+// matches the tree.
+type treemap struct {
+	// selected is the name of the selected entry.
+	selected string
+	keys     treemapKeys
+}
+
+type treemapKeys struct {
+	left   key.Binding
+	right  key.Binding
+	open   key.Binding
+	parent key.Binding
+}
+
+func newTreemapKeys() treemapKeys {
+	return treemapKeys{
+		left:   binding("left", "left", "h"),
+		right:  binding("right", "right", "l"),
+		open:   binding("open directory", "enter"),
+		parent: binding("parent directory", "backspace", "<"),
+	}
+}
+
+// tile is an entry of the current folder laid out in the treemap. The
+// rectangle covers the columns [x0, x1) and rows [y0, y1).
+type tile struct {
+	name           string
+	isDir          bool
+	size           int64
+	x0, y0, x1, y1 int
+}
+
+// rect is a rectangle in the fractional coordinates used while laying out.
+type rect struct {
+	x, y, w, h float64
+}
+
+// squarify lays out values, sorted largest first, in r so that the
+// rectangles are as close to squares as possible, following Bruls, Huizing
+// and van Wijk. The values have to add up to the area of r.
+func squarify(values []float64, r rect) []rect {
+	rects := make([]rect, 0, len(values))
+	for len(values) > 0 {
+		short := math.Min(r.w, r.h)
+		n := 1
+		for n < len(values) && worstRatio(values[:n+1], short) <= worstRatio(values[:n], short) {
+			n++
+		}
+		row := values[:n]
+		var sum float64
+		for _, v := range row {
+			sum += v
+		}
+
+		if r.w >= r.h {
+			// a column along the left side
+			width := sum / r.h
+			y := r.y
+			for _, v := range row {
+				h := v / width
+				rects = append(rects, rect{r.x, y, width, h})
+				y += h
+			}
+			r.x += width
+			r.w -= width
+		} else {
+			// a row along the top
+			height := sum / r.w
+			x := r.x
+			for _, v := range row {
+				w := v / height
+				rects = append(rects, rect{x, r.y, w, height})
+				x += w
+			}
+			r.y += height
+			r.h -= height
+		}
+		values = values[n:]
+	}
+	return rects
+}
+
+// worstRatio returns the largest aspect ratio of the rectangles of row when
+// laid out along a side of length side.
+func worstRatio(row []float64, side float64) float64 {
+	var sum, min, max float64
+	min = math.Inf(1)
+	for _, v := range row {
+		sum += v
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	s2, w2 := sum*sum, side*side
+	return math.Max(w2*max/s2, s2/(w2*min))
+}
+
+// treemapSize returns the number of columns and rows the treemap gets.
+func (m Model) treemapSize() (int, int) {
+	// title, status and help lines
+	return max(m.width, 0), max(m.height-4, 0)
+}
+
+// treemapTiles lays out the visible entries of the current folder. Entries
+// that would end up smaller than a cell are left out.
+func (m Model) treemapTiles() []tile {
+	width, height := m.treemapSize()
+	if width < 1 || height < 1 {
+		return nil
+	}
+
+	tiles := []tile{}
+	t := m.Tree
+	for _, id := range t.Children(m.Current) {
+		if name := t.Name(id); !m.hidden(name, t.Excluded(id)) && !t.Excluded(id) {
+			tiles = append(tiles, tile{name: name, isDir: t.IsDir(id), size: m.size(t.Size(id), t.ApparentSize(id))})
+		}
+	}
+	sort.SliceStable(tiles, func(i, j int) bool {
+		if tiles[i].size != tiles[j].size {
+			return tiles[i].size > tiles[j].size
+		}
+		return tiles[i].name < tiles[j].name
+	})
+	for len(tiles) > 0 && tiles[len(tiles)-1].size <= 0 {
+		tiles = tiles[:len(tiles)-1]
+	}
+	var total float64
+	for _, t := range tiles {
+		total += float64(t.size)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// a cell is about twice as high as it is wide, so lay out in half
+	// columns to get squares on screen
+	area := rect{0, 0, float64(width), float64(height) * 2}
+	values := make([]float64, len(tiles))
+	for i, t := range tiles {
+		values[i] = float64(t.size) / total * area.w * area.h
+	}
+	laidOut := tiles[:0]
+	for i, r := range squarify(values, area) {
+		t := tiles[i]
+		t.x0, t.x1 = int(math.Round(r.x)), int(math.Round(r.x+r.w))
+		t.y0, t.y1 = int(math.Round(r.y/2)), int(math.Round((r.y+r.h)/2))
+		if t.x1 > t.x0 && t.y1 > t.y0 {
+			laidOut = append(laidOut, t)
+		}
+	}
+	return laidOut
+}
+
+// selectedTile returns the index of the selected tile, or the largest one.
+func (m Model) selectedTile(tiles []tile) int {
+	for i, t := range tiles {
+		if t.name == m.treemap.selected {
+			return i
+		}
+	}
+	return 0
+}
+
+// moveTreemap selects the nearest tile in the direction (dx, dy).
+func (m *Model) moveTreemap(dx, dy int) {
+	tiles := m.treemapTiles()
+	if len(tiles) == 0 {
+		return
+	}
+	cur := tiles[m.selectedTile(tiles)]
+	cx, cy := float64(cur.x0+cur.x1)/2, float64(cur.y0+cur.y1)/2
+
+	best, bestDist := -1, math.Inf(1)
+	for i, t := range tiles {
+		// only tiles lying beyond the edge of the current one
+		switch {
+		case dx < 0 && t.x1 > cur.x0, dx > 0 && t.x0 < cur.x1,
+			dy < 0 && t.y1 > cur.y0, dy > 0 && t.y0 < cur.y1:
+			continue
+		}
+		x, y := float64(t.x0+t.x1)/2, float64(t.y0+t.y1)/2
+		// prefer tiles straight ahead over ones off to the side
+		along, across := math.Abs(x-cx), math.Abs(y-cy)*2
+		if dx == 0 {
+			along, across = math.Abs(y-cy)*2, math.Abs(x-cx)
+		}
+		if d := along + 2*across; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best >= 0 {
+		m.treemap.selected = tiles[best].name
+	}
+}
+
+// updateTreemap handles the keys of the treemap view.
+func (m Model) updateTreemap(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	k := m.treemap.keys
+	switch {
+	case msg.Type == tea.KeyEsc, key.Matches(msg, m.keys.treemap, m.keys.quit):
+		m.treemap = nil
+	case key.Matches(msg, m.keys.help):
+		m.showHelp = true
+	case key.Matches(msg, k.left):
+		m.moveTreemap(-1, 0)
+	case key.Matches(msg, k.right):
+		m.moveTreemap(1, 0)
+	case key.Matches(msg, m.keys.up):
+		m.moveTreemap(0, -1)
+	case key.Matches(msg, m.keys.down):
+		m.moveTreemap(0, 1)
+	case key.Matches(msg, k.open):
+		tiles := m.treemapTiles()
+		if len(tiles) > 0 {
+			if t := tiles[m.selectedTile(tiles)]; t.isDir {
+				m.enter(t.name)
+				m.treemap.selected = ""
+			}
+		}
+	case key.Matches(msg, k.parent):
+		if m.Current != m.Tree.Root() {
+			name := m.Tree.Name(m.Current)
+			m.leave()
+			m.treemap.selected = name
+		}
+	}
+	return m, nil
+}
+
+// treemapView draws the current folder as nested boxes sized by their share
+// of the folder.
+func (m Model) treemapView() string {
+	width, height := m.treemapSize()
+	tiles := m.treemapTiles()
+	selected := m.selectedTile(tiles)
+
+	// every cell remembers which tile it belongs to, for colouring
+	cells := make([][]rune, height)
+	owner := make([][]int, height)
+	for y := range cells {
+		cells[y] = []rune(strings.Repeat(" ", width))
+		owner[y] = make([]int, width)
+		for x := range owner[y] {
+			owner[y][x] = -1
+		}
+	}
+
+	for i, t := range tiles {
+		border := []rune("┌┐└┘─│")
+		if i == selected {
+			border = []rune("╔╗╚╝═║")
+		}
+		// tiles too small for a border are just coloured
+		bordered := t.x1-t.x0 >= 2 && t.y1-t.y0 >= 2
+		for y := t.y0; y < t.y1; y++ {
+			for x := t.x0; x < t.x1; x++ {
+				owner[y][x] = i
+				if !bordered {
+					continue
+				}
+				top, bottom, left, right := y == t.y0, y == t.y1-1, x == t.x0, x == t.x1-1
+				switch {
+				case top && left:
+					cells[y][x] = border[0]
+				case top && right:
+					cells[y][x] = border[1]
+				case bottom && left:
+					cells[y][x] = border[2]
+				case bottom && right:
+					cells[y][x] = border[3]
+				case top || bottom:
+					cells[y][x] = border[4]
+				case left || right:
+					cells[y][x] = border[5]
+				}
+			}
+		}
+
+		// the name and size inside the border, as far as they fit
+		x0, y0, x1, y1 := t.x0, t.y0, t.x1, t.y1
+		if bordered {
+			x0, y0, x1, y1 = x0+1, y0+1, x1-1, y1-1
+		}
+		labels := []string{t.name, m.formatSize(t.size)}
+		if t.isDir {
+			labels[0] += string(filepath.Separator)
+		}
+		for l, label := range labels {
+			y := y0 + l
+			if y >= y1 || x1 <= x0 {
+				break
+			}
+			for j, r := range []rune(truncate(label, x1-x0)) {
+				cells[y][x0+j] = r
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title()))
+	b.WriteString("\n\n")
+	for y := range cells {
+		// draw runs of cells of the same tile with one style
+		for x := 0; x < width; {
+			end := x
+			for end < width && owner[y][end] == owner[y][x] {
+				end++
+			}
+			text := string(cells[y][x:end])
+			switch i := owner[y][x]; {
+			case i < 0:
+				b.WriteString(text)
+			case i == selected:
+				b.WriteString(treemapSelectedStyle.Render(text))
+			default:
+				b.WriteString(lipgloss.NewStyle().Background(treemapColors[i%len(treemapColors)]).Render(text))
+			}
+			x = end
+		}
+		b.WriteString("\n")
+	}
+
+	if len(tiles) > 0 {
+		t := tiles[selected]
+		total := m.size(m.Tree.Size(m.Current), m.Tree.ApparentSize(m.Current))
+		percent := 0.0
+		if total > 0 {
+			percent = float64(t.size) / float64(total) * 100
+		}
+		b.WriteString(statusMessageStyle(fmt.Sprintf("%s  %s  %.1f%%", t.name, m.formatSize(t.size), percent)))
+	} else {
+		b.WriteString(statusMessageStyle("Nothing to show"))
+	}
+	b.WriteString("\n")
+	k := m.treemap.keys
+	help := []key.Binding{m.keys.up, m.keys.down, k.left, k.right, k.open, k.parent, m.keys.treemap}
+	parts := make([]string, 0, len(help))
+	for _, h := range help {
+		parts = append(parts, h.Help().Key+" "+h.Help().Desc)
+	}
+	b.WriteString(lipgloss.NewStyle().Faint(true).Render(truncate(strings.Join(parts, " • "), width)))
+	return b.String()
+}
+
+// truncate shortens s to at most width runes, marking the cut with an
+// ellipsis.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
@@ -2,14 +2,17 @@ package tui
 
 import (
 	"fmt"
-	. "internal/du"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/google/go-cmp/cmp"
+	. "github.com/davleop/godu/scan"
+	"github.com/muesli/termenv"
 )
 
 var (
@@ -23,15 +26,10 @@ var (
 	statusMessageStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
 				Render
-)
-
-type listMsg bool
 
-func updateList() tea.Cmd {
-	return func() tea.Msg {
-		return listMsg(true)
-	}
-}
+	graphStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"})
+)
 
 type Order int64
 
@@ -40,25 +38,44 @@ const (
 	Name
 	Size
 	ModTime
+	ApparentSize
+	ItemCount
 )
 
 type Model struct {
-	// This section is for maintaining the `du` content
-	CurrentFolder Folder
-	Root          Folder
-	Stack         []Folder
+	// This section is for maintaining the `du` content. Every view works
+	// on the same Tree, so changes made from one are seen by all of them.
+	Tree    *Tree
+	Current NodeID
 
 	// other options
-	ListOrder      Order
-	Descending     bool
-	ShowHidden     bool
-	DirectoryFirst bool
+	Options
 
 	// the rest is for actually maintaining the TUI display
-	list         list.Model
-	keys         *listKeyMap
-	delegateKeys *delegateKeyMap
-	Version      string
+	list    list.Model
+	keys    *keyMap
+	dialog  *dialog
+	report  *report
+	treemap *treemap
+	marked  map[string]bool
+	// search is kept after the prompt is closed to start the next search
+	// from it.
+	search    *searchPrompt
+	searching bool
+	showHelp  bool
+	width     int
+	height    int
+	Version   string
+	// watcher reports changes on disk when watching, and watchErr is the
+	// error met while setting it up, until it is shown.
+	watcher  *Watcher
+	watchErr error
+	// Exported is the file the tree was written to when quitting with
+	// export, for the caller to tell where it went.
+	Exported string
+
+	// Scan is used to rescan directories when refreshing.
+	Scan ScanOptions
 }
 
 func (o Order) String() string {
@@ -69,6 +86,10 @@ func (o Order) String() string {
 		return "size"
 	case ModTime:
 		return "modify"
+	case ApparentSize:
+		return "apparent"
+	case ItemCount:
+		return "items"
 	}
 	return "unknown"
 }
@@ -76,248 +97,298 @@ func (o Order) String() string {
 type item struct {
 	title       string
 	description string
-	bck         *Model
+	name        string
+	isDir       bool
+	key         sortKey
 }
 
 func (i item) Title() string       { return i.title }
 func (i item) Description() string { return i.description }
-func (i item) Bck() *Model         { return i.bck }
-func (i item) FilterValue() string { return i.title }
-
-type listKeyMap struct {
-	toggleSpinner    key.Binding
-	toggleTitleBar   key.Binding
-	toggleStatusBar  key.Binding
-	togglePagination key.Binding
-	toggleHelpMenu   key.Binding
-	insertItem       key.Binding
+func (i item) FilterValue() string { return i.name }
+
+func (m Model) updateCurrentFiles(folder NodeID) []list.Item {
+	t := m.Tree
+	var folders, files []list.Item
+	for _, id := range t.Children(folder) {
+		name := t.Name(id)
+		if m.hidden(name, t.Excluded(id)) {
+			continue
+		}
+		if t.IsDir(id) {
+			folders = append(folders, item{
+				title: m.formatFolderItemTitle(id),
+				name:  name,
+				isDir: true,
+				key:   sortKey{name, t.Size(id), t.ApparentSize(id), t.Items(id), t.ModTime(id)},
+			})
+			continue
+		}
+		files = append(files, item{
+			title: m.formatFileItemTitle(id),
+			name:  name,
+			key:   sortKey{name, t.Size(id), t.ApparentSize(id), 0, t.ModTime(id)},
+		})
+	}
+
+	var items []list.Item
+	if m.DirectoryFirst {
+		m.sortItems(folders)
+		m.sortItems(files)
+		items = append(folders, files...)
+	} else {
+		items = append(folders, files...)
+		m.sortItems(items)
+	}
+
+	if m.Current != m.Tree.Root() {
+		tmp := []list.Item{item{title: fmt.Sprintf("%-2s %s", "", ".."), name: ".."}}
+		items = append(tmp, items...)
+	}
+	return items
 }
 
-func newListKeyMap() *listKeyMap {
-	return &listKeyMap{
-		insertItem: key.NewBinding(
-			key.WithKeys("a"),
-			key.WithHelp("a", "add item"),
-		),
-		toggleSpinner: key.NewBinding(
-			key.WithKeys("s"),
-			key.WithHelp("s", "toggle spinner"),
-		),
-		toggleTitleBar: key.NewBinding(
-			key.WithKeys("T"),
-			key.WithHelp("T", "toggle title"),
-		),
-		toggleStatusBar: key.NewBinding(
-			key.WithKeys("S"),
-			key.WithHelp("S", "toggle status"),
-		),
-		togglePagination: key.NewBinding(
-			key.WithKeys("P"),
-			key.WithHelp("P", "toggle pagination"),
-		),
-		toggleHelpMenu: key.NewBinding(
-			key.WithKeys("H"),
-			key.WithHelp("H", "toggle help"),
-		),
+// hidden reports whether an entry is left out of the listing. Hidden entries
+// still count towards the totals of their folder.
+func (m Model) hidden(name string, excluded bool) bool {
+	return !m.ShowHidden && (excluded || strings.HasPrefix(name, "."))
+}
+
+// hiddenCount returns how many entries of folder are currently hidden.
+func (m Model) hiddenCount(folder NodeID) int {
+	n := 0
+	for _, id := range m.Tree.Children(folder) {
+		if m.hidden(m.Tree.Name(id), m.Tree.Excluded(id)) {
+			n++
+		}
 	}
+	return n
 }
 
-func (m Model) updateCurrentFiles(folder Folder) []list.Item {
-	if m.DirectoryFirst {
-		/*switch m.ListOrder {
-		case Name:
-		case Size:
-		case ModTime:
-		}*/
-		fileCount := len(folder.Files)
-		folderCount := len(folder.Folders)
-		totalCount := fileCount + folderCount
-
-		items := make([]list.Item, totalCount)
-
-		for i := 0; i < folderCount; i++ {
-			title := m.formatFolderItemTitle(m.CurrentFolder.Folders[i])
-			items[i] = item{title: title, bck: &m}
+// sortKey holds the columns an item can be sorted on.
+type sortKey struct {
+	name         string
+	size         int64
+	apparentSize int64
+	items        int64
+	modTime      time.Time
+}
+
+// sortItems orders items by m.ListOrder. Ties are broken by name so the
+// order is stable between refreshes.
+func (m Model) sortItems(items []list.Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return m.less(items[i].(item).key, items[j].(item).key)
+	})
+}
+
+func (m Model) less(a, b sortKey) bool {
+	switch m.ListOrder {
+	case Name:
+		if a.name != b.name {
+			return (a.name < b.name) != m.Descending
 		}
-		j := 0
-		for i := folderCount; i < totalCount; i++ {
-			title := m.formatFileItemTitle(m.CurrentFolder.Files[j])
-			items[i] = item{title: title, bck: &m}
-			j++
+	case Size:
+		if a.size != b.size {
+			return (a.size < b.size) != m.Descending
 		}
-		if !cmp.Equal(m.CurrentFolder, m.Root) {
-			//"%-2s %8s %-9s   %s/"
-			tmp := make([]list.Item, 1)
-			tmp[0] = item{title: "                          ..", bck: &m}
-			items = append(tmp, items...)
+	case ApparentSize:
+		if a.apparentSize != b.apparentSize {
+			return (a.apparentSize < b.apparentSize) != m.Descending
 		}
-		return items
-	} else {
-		items := make([]list.Item, 1)
-		items[0] = item{title: "test", bck: &m}
-		return items
-	}
-	// TODO(david): add filter checks here...
-	/*for _, file := range m.Files {
-		if !m.ShowHidden && strings.HasPrefix(file.Name, ".") {
-			continue
+	case ItemCount:
+		if a.items != b.items {
+			return (a.items < b.items) != m.Descending
 		}
-		if file.HighDir == m.CurrentDirectory && file.Name != m.CurrentDirectory {
-			if file.IsDir {
-				m.currentDirectories = append(m.currentDirectories, file)
-			} else {
-				m.currentFiles = append(m.currentFiles, file)
-			}
+	case ModTime:
+		if !a.modTime.Equal(b.modTime) {
+			return a.modTime.Before(b.modTime) != m.Descending
 		}
 	}
+	return a.name < b.name
+}
 
-	// Calculate usage of currently displayed items
+func (m Model) formatSize(size int64) string {
+	if m.SI {
+		return PrettyPrintSizeSI(size)
+	}
+	return PrettyPrintSize(size)
+}
 
-	// TODO(david): there's probably a prettier way to do this, but it'll  work
-	if !m.DirectoryFirst {
-		m.currentFiles = append(m.currentFiles, m.currentDirectories...)
+// size returns the size of an entry that is currently being displayed.
+func (m Model) size(diskUsage, apparentSize int64) int64 {
+	if m.UseApparentSize {
+		return apparentSize
+	}
+	return diskUsage
+}
 
-		switch m.ListOrder {
-		case Name:
-			sort.Sort(NameSorter(m.currentFiles))
-		case Size:
-			sort.SliceStable(m.currentFiles, func(i, j int) bool {
-				return m.currentFiles[i].Size > m.currentFiles[j].Size
-			})
-		case ModTime:
-			sort.Sort(TimeSorter(m.currentFiles))
-		}
+func (m Model) title() string {
+	size := m.size(m.Tree.Size(m.Current), m.Tree.ApparentSize(m.Current))
+	title := fmt.Sprintf("godu-%s | Total: %s | %s", m.Version, m.formatSize(size), m.Tree.Path(m.Current))
+	if n := m.hiddenCount(m.Current); n > 0 {
+		title += fmt.Sprintf(" | %d hidden", n)
+	}
+	if m.Scan.OlderThan > 0 {
+		title += " | older than " + formatAge(m.Scan.OlderThan)
+	}
+	if len(m.marked) > 0 {
+		title += fmt.Sprintf(" | %d marked (%s)", len(m.marked), m.formatSize(m.markedSize()))
+	}
+	return title
+}
 
-		fileCount := len(m.currentFiles)
-		items := make([]list.Item, fileCount)
-		for i := 0; i < fileCount; i++ {
-			title := m.formatItemTitle(m.currentFiles[i])
-			items[i] = item{title: title}
-		}
-		return items
-	} else {
-		switch m.ListOrder {
-		case Name:
-			sort.Sort(NameSorter(m.currentDirectories))
-			sort.Sort(NameSorter(m.currentFiles))
-		case Size:
-			sort.SliceStable(m.currentDirectories, func(i, j int) bool {
-				return m.currentDirectories[i].Size > m.currentDirectories[j].Size
-			})
-			sort.SliceStable(m.currentFiles, func(i, j int) bool {
-				return m.currentFiles[i].Size > m.currentFiles[j].Size
-			})
-		case ModTime:
-			sort.Sort(TimeSorter(m.currentDirectories))
-			sort.Sort(TimeSorter(m.currentFiles))
-		}
+// refresh rebuilds the listing after the current folder or one of the
+// display options changed.
+func (m *Model) refresh() {
+	m.list.SetItems(m.updateCurrentFiles(m.Current))
+	m.list.Title = m.title()
+}
 
-		// Create list view
-		directoryCount := len(m.currentDirectories)
-		fileCount := len(m.currentFiles)
-		totalCount := directoryCount + fileCount
-		items := make([]list.Item, totalCount)
+// enter makes the subfolder called name the current folder.
+func (m *Model) enter(name string) {
+	if id, ok := m.Tree.Child(m.Current, name); ok && m.Tree.IsDir(id) {
+		m.Current = id
+		m.refresh()
+		m.list.Select(0)
+	}
+}
 
-		if m.DirectoryFirst {
-			for i := 0; i < directoryCount; i++ {
-				title := m.formatItemTitle(m.currentDirectories[i])
-				items[i] = item{title: title}
-			}
-			j := 0
-			for i := directoryCount; i < totalCount; i++ {
-				title := m.formatItemTitle(m.currentFiles[j])
-				items[i] = item{title: title}
-				j++
-			}
-			return items
+// leave goes back up to the parent of the current folder.
+func (m *Model) leave() {
+	parent := m.Tree.Parent(m.Current)
+	if parent == NoNode {
+		return
+	}
+	m.Current = parent
+	m.refresh()
+	m.list.Select(0)
+}
+
+// formatColumns renders everything in front of the name of an entry:
+// F SSS.S PPP.P% [BBBBBBBBBB] CCCCCC YYYY-MM-DD HH:MM
+func (m Model) formatColumns(mode string, size, items int64, isDir bool, modTime time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-2s %8s", mode, m.formatSize(size))
+
+	total := m.size(m.Tree.Size(m.Current), m.Tree.ApparentSize(m.Current))
+	n := 0.0
+	if total > 0 {
+		n = float64(size) / float64(total)
+	}
+	if m.ShowPercent {
+		fmt.Fprintf(&b, " %5.1f%%", n*100)
+	}
+	if m.ShowGraph {
+		fmt.Fprintf(&b, " [%s]", m.graph(n, 10))
+	}
+	if m.ShowItemCount {
+		if isDir {
+			fmt.Fprintf(&b, " %6d", items)
 		} else {
-			for i := 0; i < fileCount; i++ {
-				title := m.formatItemTitle(m.currentFiles[i])
-				items[i] = item{title: title}
-			}
-			j := 0
-			for i := fileCount; i < totalCount; i++ {
-				title := m.formatItemTitle(m.currentDirectories[j])
-				items[i] = item{title: title}
-				j++
-			}
-			return items
+			fmt.Fprintf(&b, " %6s", "")
 		}
-	}*/
-
+	}
+	if m.Extended && m.ShowMTime {
+		fmt.Fprintf(&b, " %s", modTime.Format("2006-01-02 15:04"))
+	}
+	return b.String()
 }
 
-func (m Model) formatFileItemTitle(file File) string {
-	// this should formatted eventually as so:
-	// F SSS.S UUU [BBBBBBBBB] filename -->
-	// TODO(david): calculate sizes later
-	prog := progress.New(progress.WithScaledGradient("#00FF00", "#FF0000"))
-	prog.Width = 11
-	n := 0.0
-	humanSize := file.HumanSize
-	n = float64(file.Size) / float64(m.Root.Size)
-	graph := prog.ViewAs(n)
+var eighthBlocks = []rune(" ▏▎▍▌▋▊▉█")
+
+// graph draws a bar of width cells filled to n using m.GraphStyle.
+func (m Model) graph(n float64, width int) string {
+	if n < 0 {
+		n = 0
+	} else if n > 1 {
+		n = 1
+	}
+
+	var bar string
+	switch m.GraphStyle {
+	case HalfBlock:
+		halves := int(n*float64(width)*2 + 0.5)
+		bar = strings.Repeat("█", halves/2) + strings.Repeat("▌", halves%2)
+	case EighthBlock:
+		eighths := int(n*float64(width)*8 + 0.5)
+		bar = strings.Repeat("█", eighths/8)
+		if eighths%8 != 0 {
+			bar += string(eighthBlocks[eighths%8])
+		}
+	default:
+		bar = strings.Repeat("#", int(n*float64(width)+0.5))
+	}
+	bar += strings.Repeat(" ", width-utf8.RuneCountInString(bar))
+	return graphStyle.Render(bar)
+}
 
+func (m Model) formatFileItemTitle(id NodeID) string {
+	t := m.Tree
 	// setting `F` here
 	mode := " "
-	if !file.Mode.IsRegular() {
+	if t.Excluded(id) {
+		mode = "<"
+	} else if !t.Mode(id).IsRegular() {
 		mode = "@"
 	}
-	return fmt.Sprintf("%-2s %8s %-9s   %s", mode, humanSize, graph, file.Name)
+	if len(m.marked) > 0 && m.marked[t.Path(id)] {
+		mode = strings.TrimSpace(mode) + "*"
+	}
+	columns := m.formatColumns(mode, m.size(t.Size(id), t.ApparentSize(id)), 0, false, t.ModTime(id))
+	return fmt.Sprintf("%s   %s", columns, t.Name(id))
 }
 
-func (m Model) formatFolderItemTitle(file Folder) string {
-	// this should formatted eventually as so:
-	// F SSS.S UUU [BBBBBBBBB] filename -->
-	// TODO(david): calculate sizes later
-	prog := progress.New(progress.WithScaledGradient("#00FF00", "#FF0000"))
-	prog.Width = 11
-	n := 0.0
-	humanSize := file.HumanSize
-	n = float64(file.Size) / float64(m.Root.Size)
-	humanSize = file.HumanSize
-	graph := prog.ViewAs(n)
-
+func (m Model) formatFolderItemTitle(id NodeID) string {
+	t := m.Tree
 	// setting `F` here
 	mode := " "
-
-	return fmt.Sprintf("%-2s %8s %-9s   %s/", mode, humanSize, graph, file.Name)
+	if t.Excluded(id) {
+		mode = "<"
+	}
+	if len(m.marked) > 0 && m.marked[t.Path(id)] {
+		mode = strings.TrimSpace(mode) + "*"
+	}
+	columns := m.formatColumns(mode, m.size(t.Size(id), t.ApparentSize(id)), t.Items(id), true, t.ModTime(id))
+	return fmt.Sprintf("%s   %s/", columns, t.Name(id))
 }
-func NewModel(m Model) Model {
-	var (
-		delegateKeys = newDelegateKeyMap()
-		listKeys     = newListKeyMap()
-	)
 
-	items := m.updateCurrentFiles(m.CurrentFolder)
+// NewModel sets up the browser for m, which has its Tree, Current folder and
+// Options filled in. It fails if Options.KeyBindings can't be applied.
+func NewModel(m Model) (Model, error) {
+	m.marked = map[string]bool{}
+	keys := newKeyMap()
+	if err := keys.rebind(m.KeyBindings); err != nil {
+		return m, fmt.Errorf("error binding keys: %w", err)
+	}
+
+	switch m.Color {
+	case ColorOff:
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case ColorDark:
+		lipgloss.SetHasDarkBackground(true)
+	}
+
+	items := m.updateCurrentFiles(m.Current)
 
 	// Setup list
-	delegate := newItemDelegate(delegateKeys)
+	delegate := newItemDelegate(keys)
 	delegate.ShowDescription = false
 	currentFiles := list.New(items, delegate, 0, 0)
-	title := fmt.Sprintf("godu-%s | Total: %s | %s", m.Version, PrettyPrintSize(m.CurrentFolder.Size), m.CurrentFolder.Path)
-	currentFiles.Title = title
+	currentFiles.Title = m.title()
 	currentFiles.Styles.Title = titleStyle
-	currentFiles.AdditionalFullHelpKeys = func() []key.Binding {
-		return []key.Binding{
-			listKeys.toggleSpinner,
-			listKeys.insertItem,
-			listKeys.toggleTitleBar,
-			listKeys.toggleStatusBar,
-			listKeys.togglePagination,
-			listKeys.toggleHelpMenu,
-		}
-	}
+	keys.apply(&currentFiles)
 
 	m.list = currentFiles
-	m.keys = listKeys
-	m.delegateKeys = delegateKeys
+	m.keys = keys
 
-	return m
+	if m.Watch {
+		m.startWatching()
+	}
+	return m, nil
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.EnterAltScreen
+	return tea.Batch(tea.EnterAltScreen, m.waitForChanges())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -326,54 +397,106 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		h, v := appStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		m.width, m.height = msg.Width-h, msg.Height-v
+		m.list.SetSize(m.width, m.height)
+		if m.report != nil {
+			m.report.list.SetSize(m.width, m.height)
+		}
 
 	case tea.KeyMsg:
+		if m.dialog != nil {
+			return m.dialog.update(m, msg)
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if m.showHelp {
+			if key.Matches(msg, m.keys.help, m.keys.quit) || msg.Type == tea.KeyEsc {
+				m.showHelp = false
+			}
+			return m, nil
+		}
+		if m.report != nil && !key.Matches(msg, m.list.KeyMap.ForceQuit) {
+			return m.updateReport(msg)
+		}
+		if m.treemap != nil && !key.Matches(msg, m.list.KeyMap.ForceQuit) {
+			return m.updateTreemap(msg)
+		}
+		if key.Matches(msg, m.list.KeyMap.ForceQuit) ||
+			(m.list.FilterState() != list.Filtering && key.Matches(msg, m.list.KeyMap.Quit)) {
+			if !m.ConfirmQuit {
+				return m, tea.Quit
+			}
+			m.dialog = m.quitDialog()
+			return m, nil
+		}
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
-
-		switch {
-		case key.Matches(msg, m.keys.toggleSpinner):
-			cmd := m.list.ToggleSpinner()
+		if handled, cmd := m.handleKey(msg); handled {
 			return m, cmd
+		}
 
-		case key.Matches(msg, m.keys.toggleTitleBar):
-			v := !m.list.ShowTitle()
-			m.list.SetShowTitle(v)
-			m.list.SetShowFilter(v)
-			m.list.SetFilteringEnabled(v)
-			return m, nil
+	case duplicatesMsg:
+		m.Tree.SetHashes(msg.files)
+		return m, m.showDuplicates(msg.groups)
 
-		case key.Matches(msg, m.keys.toggleStatusBar):
-			m.list.SetShowStatusBar(!m.list.ShowStatusBar())
-			return m, nil
+	case identicalFoldersMsg:
+		m.Tree.SetHashes(msg.files)
+		return m, m.showIdenticalFolders(m.Tree.IdenticalFolders(m.Tree.Root()))
 
-		case key.Matches(msg, m.keys.togglePagination):
-			m.list.SetShowPagination(!m.list.ShowPagination())
+	case contentTypesMsg:
+		if msg.path != m.Tree.Path(m.Current) {
 			return m, nil
+		}
+		return m, m.showTypes(msg.groups, true)
 
-		case key.Matches(msg, m.keys.toggleHelpMenu):
-			m.list.SetShowHelp(!m.list.ShowHelp())
-			return m, nil
+	case watchMsg:
+		return m, m.applyChanges(msg)
 
-		case key.Matches(msg, m.keys.insertItem):
-			m.delegateKeys.remove.SetEnabled(true)
-			return m, nil
+	case clipboardMsg:
+		if msg.err != nil {
+			return m, m.notify("error copying to the clipboard: %v", msg.err)
 		}
+		return m, m.notify("Copied %d paths to the clipboard", msg.paths)
 
-	case listMsg:
-		// do nothing!
+	case shellDoneMsg:
+		if msg.err != nil {
+			cmds = append(cmds, m.status("Shell exited: %v", msg.err))
+		}
 	}
 
 	newListModel, cmd := m.list.Update(msg)
 	m.list = newListModel
 	cmds = append(cmds, cmd)
+	if m.searching {
+		m.search.input, cmd = m.search.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if m.report != nil {
+		m.report.list, cmd = m.report.list.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) View() string {
+	if m.dialog != nil {
+		return appStyle.Render(m.dialog.view(m.width, m.height))
+	}
+	if m.searching {
+		return appStyle.Render(m.searchView())
+	}
+	if m.showHelp {
+		return appStyle.Render(m.helpView())
+	}
+	if m.report != nil {
+		return appStyle.Render(m.report.list.View())
+	}
+	if m.treemap != nil {
+		return appStyle.Render(m.treemapView())
+	}
 	return appStyle.Render(m.list.View())
 }
 
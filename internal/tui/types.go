@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+type contentTypesMsg struct {
+	path   string
+	groups []TypeGroup
+}
+
+// typesItem is a category of the file types report.
+type typesItem struct {
+	title string
+	group TypeGroup
+}
+
+func (i typesItem) Title() string       { return i.title }
+func (i typesItem) Description() string { return "" }
+func (i typesItem) FilterValue() string { return i.group.Name }
+
+// findContentTypes detects the type of every file below the current folder
+// in the background. Only the list of files is taken from the tree, which
+// can change meanwhile.
+func (m *Model) findContentTypes() tea.Cmd {
+	path, files := m.Tree.Path(m.Current), m.Tree.Files(m.Current)
+	status := m.status("Detecting file types...")
+	return tea.Batch(status, func() tea.Msg {
+		return contentTypesMsg{path, files.ByContentType()}
+	})
+}
+
+// typeName returns how a category is shown.
+func typeName(name string, byContent bool) string {
+	switch {
+	case name != "":
+		return name
+	case byContent:
+		return "unknown"
+	}
+	return "(no extension)"
+}
+
+// showTypes opens the breakdown of the current folder by file type, either
+// by extension or by the content type detected in extended mode. The tab key
+// switches between the two.
+func (m *Model) showTypes(groups []TypeGroup, byContent bool) tea.Cmd {
+	b := breakdown{
+		kind:     "extension",
+		groups:   groups,
+		name:     func(name string) string { return typeName(name, byContent) },
+		switchTo: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "extension / type")),
+	}
+	if byContent {
+		b.kind = "type"
+	}
+	b.switchFn = func(m *Model) tea.Cmd {
+		if byContent {
+			m.closeReport()
+			return m.showTypes(m.Tree.ByExtension(m.Current), false)
+		}
+		if !m.Extended {
+			return m.reportStatus("Detecting file types needs extended mode (-e)")
+		}
+		m.closeReport()
+		return m.findContentTypes()
+	}
+	m.showBreakdown(b)
+	return nil
+}
+
+// breakdown is the files below the current folder grouped by some property,
+// such as their extension or owner.
+type breakdown struct {
+	kind   string
+	groups []TypeGroup
+	// name returns how a group is shown.
+	name func(name string) string
+	// switchTo switches to another grouping by running switchFn.
+	switchTo key.Binding
+	switchFn func(m *Model) tea.Cmd
+}
+
+// showBreakdown opens a report with the size, share and number of files of
+// every group. open lists the files of the selected group.
+func (m *Model) showBreakdown(b breakdown) {
+	path := m.Tree.Path(m.Current)
+	title := func(m *Model) string {
+		return fmt.Sprintf("Files by %s | %s", b.kind, path)
+	}
+	items := func(m *Model) []list.Item {
+		// drop what was deleted since the groups were made
+		current := make([]TypeGroup, 0, len(b.groups))
+		var total int64
+		for _, g := range b.groups {
+			files := []Entry{}
+			var size, apparent int64
+			for _, f := range g.Files {
+				if m.Tree.Contains(f.ID) {
+					files = append(files, f)
+					size += f.Size
+					apparent += f.ApparentSize
+				}
+			}
+			if len(files) > 0 {
+				current = append(current, TypeGroup{Name: g.Name, Size: size, ApparentSize: apparent, Files: files})
+				total += m.size(size, apparent)
+			}
+		}
+		sort.SliceStable(current, func(i, j int) bool {
+			return m.size(current[i].Size, current[i].ApparentSize) > m.size(current[j].Size, current[j].ApparentSize)
+		})
+
+		items := []list.Item{}
+		for _, g := range current {
+			size := m.size(g.Size, g.ApparentSize)
+			percent := 0.0
+			if total > 0 {
+				percent = float64(size) / float64(total) * 100
+			}
+			items = append(items, typesItem{
+				title: fmt.Sprintf("%8s %5.1f%% %8d files  %s",
+					m.formatSize(size), percent, len(g.Files), b.name(g.Name)),
+				group: g,
+			})
+		}
+		return items
+	}
+
+	r := m.openReport(title(m), items(m), []key.Binding{m.keys.open, b.switchTo})
+	r.rebuild = func(m *Model) []list.Item {
+		m.report.list.Title = title(m)
+		return items(m)
+	}
+	r.handle = func(m *Model, msg tea.KeyMsg) (bool, tea.Cmd) {
+		switch {
+		case key.Matches(msg, b.switchTo):
+			return true, b.switchFn(m)
+
+		case key.Matches(msg, m.keys.open):
+			i, ok := m.report.list.SelectedItem().(typesItem)
+			if !ok {
+				return true, nil
+			}
+			name := b.name(i.group.Name)
+			m.showEntries(func(m *Model) string {
+				return fmt.Sprintf("Files by %s | %s", b.kind, name)
+			}, func(m *Model) []Entry {
+				files := []Entry{}
+				for _, f := range i.group.Files {
+					if m.Tree.Contains(f.ID) {
+						files = append(files, f)
+					}
+				}
+				sort.SliceStable(files, func(a, b int) bool {
+					return m.size(files[a].Size, files[a].ApparentSize) > m.size(files[b].Size, files[b].ApparentSize)
+				})
+				return files
+			})
+			return true, nil
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"errors"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	. "github.com/davleop/godu/scan"
+)
+
+// watchDelay is how long changes are left to pile up after some came in, so
+// that a burst of them only redraws the browser a few times a second.
+const watchDelay = 200 * time.Millisecond
+
+// watchMsg carries the paths that changed on disk, or why watching them
+// failed.
+type watchMsg struct {
+	paths []string
+	err   error
+}
+
+// startWatching watches every folder of the tree. It is called before the
+// browser starts, so that nothing else uses the tree meanwhile.
+func (m *Model) startWatching() {
+	w, err := NewWatcher()
+	if err != nil {
+		m.watchErr = err
+		return
+	}
+	m.watcher = w
+	m.watchErr = m.watchTree(m.Tree.Root())
+}
+
+// watchTree watches the folder id and the ones below it.
+func (m *Model) watchTree(id NodeID) error {
+	if m.watcher == nil {
+		return nil
+	}
+	_, err := m.watcher.AddTree(m.Tree, id)
+	return err
+}
+
+// waitForChanges returns the next changes, after the error met while
+// setting up the watches if there was one.
+func (m Model) waitForChanges() tea.Cmd {
+	w, err := m.watcher, m.watchErr
+	switch {
+	case err != nil:
+		return func() tea.Msg { return watchMsg{err: err} }
+	case w == nil:
+		return nil
+	}
+	return func() tea.Msg {
+		paths, err := w.Read()
+		time.Sleep(watchDelay)
+		return watchMsg{paths: paths, err: err}
+	}
+}
+
+// applyChanges updates the tree with the changes in msg and waits for the
+// next ones.
+func (m *Model) applyChanges(msg watchMsg) tea.Cmd {
+	m.watchErr = nil
+	if msg.err != nil && m.watcher == nil {
+		return m.status("Not watching for changes: %v", msg.err)
+	}
+	var status tea.Cmd
+	switch {
+	case msg.err == nil:
+	case errors.Is(msg.err, ErrWatchLimit):
+		status = m.watchLimitStatus()
+	case errors.Is(msg.err, ErrWatchOverflow):
+		status = m.status("Some changes were missed, refresh to catch up")
+	default:
+		m.watcher.Close()
+		m.watcher = nil
+		return m.status("Stopped watching for changes: %v", msg.err)
+	}
+
+	for _, p := range msg.paths {
+		id, err := m.Tree.Update(p, m.Scan)
+		if err != nil || id == NoNode || !m.Tree.IsDir(id) {
+			continue
+		}
+		if err := m.watchTree(id); err != nil && status == nil {
+			status = m.watchLimitStatus()
+		}
+	}
+	if len(msg.paths) > 0 {
+		m.changed()
+	}
+	return tea.Batch(status, m.waitForChanges())
+}
+
+// watchLimitStatus tells that some folders aren't watched.
+func (m *Model) watchLimitStatus() tea.Cmd {
+	return m.status("Too many folders to watch, only %d of them are kept up to date", m.watcher.Len())
+}
@@ -7,365 +7,309 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
-	du "internal/du"
 	"internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/davleop/godu/scan"
 	"github.com/spf13/cobra"
 )
 
 const godu_version = "v0.1.0a"
 
+// chartMinFraction is the smallest share of the tree an entry needs to get
+// its own slice of an HTML chart.
+const chartMinFraction = 1e-5
+
 /*
 This var sets up the root command and then all other commands. The root command, according to Cobra's structure, is the first thing we hit when we run the program.
 Imagine it as an automatic constructor that's allowing us to run an instance of this program.
 */
 var rootCmd = &cobra.Command{
-	Use: "put usage example here",
+	Use: "godu [flags] [directory]",
 	//TraverseChildren: true,
-	Short: "This program shows disk usage",
-	Long:  "Put longer version of Short here",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Print(args)
-		v, _ := cmd.Flags().GetBool("version")
-		if v {
+	Short:        "This program shows disk usage",
+	Long:         "godu scans a directory (the current one by default) and lets you browse its disk usage, similar to ncdu.",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if opts.Version {
 			version()
-		} else {
-			//log.Fatal("version unavailable")
-			fmt.Println("version not checked")
+			return nil
 		}
-		l := logFlag
-		if l != "" {
-			logFile, err = os.OpenFile(l, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-			if err != nil {
-				err = fmt.Errorf("error opening log file: %w", err)
-			}
+		if err := opts.prepare(); err != nil {
+			return err
 		}
-
-		o := outputFlag
-		if o == "-" {
-			outputFile = os.Stdout
-		} else {
-			outputFile, err = os.OpenFile(o, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-			if err != nil {
-				err = fmt.Errorf("error setting output file: %w", err)
-			}
+		if opts.InputFile != "" || opts.TarFile != "" {
+			opts.applyImportDefaults(cmd.Flags())
 		}
 
-		defer func() {
-			cerr := logFile.Close()
-			if err == nil {
-				err = cerr
-			}
-		}()
-		log.SetOutput(logFile)
-
-		if len(args) == 1 {
-			dir, _ = filepath.Abs(args[0])
-		} else {
-			dir, _ = filepath.Abs(".")
-		}
-		f := inputFile
-		if f != "" {
-			//needs to have logic for setting input file
-			fmt.Println(f)
-		}
-		if extendedFlag && noExtendedFlag {
-			extendedFlag = false
-		}
-		if icFlag {
-			fmt.Println("Needs implementation of ignore configuration")
-		}
-		if xFlag {
-			fmt.Println("Needs implementation of filesystem boundaries")
-		}
-		if cfsFlag && xFlag {
-			xFlag = false
-		}
-		ex := exclude
-		if len(ex) != 0 {
-			for i := 0; i < len(ex); i++ {
-				fmt.Println("excluding", ex[i])
-			}
-		}
-		bX := bigXFlag
-		XArr := []string{}
-		if bX != "" {
-			file, err := os.Open(bX)
-			if err != nil {
-				log.Fatal(err)
-			}
-			reader := bufio.NewScanner(file)
-			for reader.Scan() {
-				XArr = append(XArr, reader.Text())
-			}
-		}
-		if symLinkFlag && noSymLinkFlag {
-			symLinkFlag = false
-		}
-		if kernFlag && exKernFlag {
-			kernFlag = false
-		}
-		zero, one, two := zeroFlag, oneFlag, twoFlag
-		if two {
-			zero = false
-			one = false
-		} else if zero && one {
-			one = false
-		}
-		if fastFlag && slowFlag {
-			fastFlag = false
-		}
-		if eShellFlag && dShellFlag {
-			eShellFlag = false
-		}
-		if eDeleteFlag && dDeleteFlag {
-			eDeleteFlag = false
-		}
-		if eRefreshFlag && dRefreshFlag {
-			eRefreshFlag = false
-		}
-		r, _ := cmd.Flags().GetCount("rFlag")
-		switch r {
-		case 1:
-			eDeleteFlag = false
-		case 2:
-			eShellFlag = false
-		}
-		if siFlag && noSiFlag {
-			noSiFlag = false
-		}
-		if duFlag && apFlag {
-			duFlag = false
+		dir, err := scanDir(args)
+		if err != nil {
+			return err
 		}
-		if shFlag && hhFlag {
-			shFlag = false
-		}
-		if sicFlag && hicFlag {
-			hicFlag = false
-		}
-		if smtFlag && hmtFlag {
-			hmtFlag = false
-		}
-		if sgFlag && hgFlag {
-			sgFlag = false
-		}
-		if spFlag && hpFlag {
-			spFlag = false
-		}
-		if gdFlag && ngdFlag {
-			gdFlag = false
-		}
-
+		return run(opts, dir)
 	},
 }
 
-var (
-	//Scan and mode selection options
-	symLinkFlag    bool
-	noSymLinkFlag  bool
-	bigXFlag       string
-	exclude        []string
-	cfsFlag        bool
-	xFlag          bool
-	icFlag         bool
-	extendedFlag   bool
-	noExtendedFlag bool
-	versionFlag    bool
-	inputFile      string
-	outputFlag     string
-	outputFile     io.Writer
-	logFlag        string
-	logFile        *os.File
-	err            error
-	dir            string
-	kernFlag       bool
-	exKernFlag     bool
-	//interface options
-	zeroFlag     bool
-	oneFlag      bool
-	twoFlag      bool
-	qFlag        bool
-	fastFlag     bool
-	slowFlag     bool
-	eShellFlag   bool
-	dShellFlag   bool
-	eDeleteFlag  bool
-	dDeleteFlag  bool
-	eRefreshFlag bool
-	dRefreshFlag bool
-	rFlag        int = 0
-	siFlag       bool
-	noSiFlag     bool
-	duFlag       bool
-	apFlag       bool
-	shFlag       bool
-	hhFlag       bool
-	sicFlag      bool
-	hicFlag      bool
-	smtFlag      bool
-	hmtFlag      bool
-	sgFlag       bool
-	hgFlag       bool
-	spFlag       bool
-	hpFlag       bool
-	gStyleFlag   string
-	sColumnFlag  string
-	sortFlag     string
-	gdFlag       bool
-	ngdFlag      bool
-	cqFlag       bool
-	cdFlag       bool
-	colorFlag    string
-)
+// opts is filled in by the flags while cobra parses the arguments.
+var opts = defaultOptions()
 
 func version() {
 	fmt.Println(godu_version)
 }
 
-func crappyCalculation(files []du.File, dir string) (map[string]int64, int64) {
-	drsz := make(map[string]int64)
-	totalSz := int64(0)
+// scanDir returns the absolute path of the directory given in args, or of
+// the current directory.
+func scanDir(args []string) (string, error) {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	return filepath.Abs(dir)
+}
 
-	for _, file := range files {
-		totalSz += file.Size
-		if file.Name == dir {
-			continue
-		}
-		if len(drsz) == 0 {
-			drsz[file.HighDir] += file.Size
-		}
-		for k, _ := range drsz {
-			if strings.HasPrefix(file.HighDir, k) {
-				drsz[file.HighDir] += file.Size
-			}
-		}
+// readPatterns reads the newline separated patterns of an --exclude-from file.
+func readPatterns(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening exclude file: %w", err)
 	}
+	defer file.Close()
 
-	return drsz, totalSz
+	patterns := []string{}
+	reader := bufio.NewScanner(file)
+	for reader.Scan() {
+		if line := reader.Text(); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, reader.Err()
 }
 
 func init() {
-	flags := rootCmd.Flags()
+	// persistent, so that the subcommands scan the same way
+	flags := rootCmd.PersistentFlags()
+	scanOpts, ui := &opts.Scan, &opts.UI
 
 	//Scan and mode selection option flags
-	flags.StringVarP(&outputFlag, "output-file", "o", "", "-o [FILE] defines file for data output")
-	flags.StringVarP(&inputFile, "input-file", "f", "", "-f [FILE] defines file for data input")
-	flags.BoolVarP(&versionFlag, "version", "v", false, "-v shows the current version of godu")
-	flags.BoolVarP(&extendedFlag, "extended", "e", false, "-e enables extended information mode")
-	flags.BoolVar(&noExtendedFlag, "no-extended", false, "disables extended information mode")
-	flags.BoolVar(&icFlag, "ignore-config", false, "--ignore-config prevents godu from attempting to load any configuration files")
-	flags.BoolVarP(&xFlag, "one-file-system", "x", false, "-x prevents godu from crossing filesystem boundaries, i.e. only count files and directories on the same filesystem as the directory being scanned")
-	flags.BoolVar(&cfsFlag, "cross-file-system", false, "--cross-file-system allows godu to cross filesystem boundaries. This is the default, but can be specified to overrule a previously given '-x'")
-	flags.StringArrayVar(&exclude, "exclude", exclude, "--exclude [PATTERN] excludes files that match PATTERN. The files will still be displayed by default, but are not counted towards the disk usage statistics. This argument can be added multiple times to add more patterns.")
-	flags.StringVarP(&bigXFlag, "exclude-from", "X", "", "-X [FILE], --exclude-from [FILE] Exclude files that match any pattern in FILE. Patterns should be separated by a newline.")
-	flags.BoolVarP(&symLinkFlag, "follow-symlinks", "L", false, "-L, --follow-symlinks follows symlinks and counts the size of the file they point to.")
-	flags.BoolVar(&noSymLinkFlag, "no-follow-symlinks", false, "does not follow symbolic links")
-	flags.BoolVar(&kernFlag, "include-kernfs", false, "(Linux only) Include (default) Linux pseudo filesystems, e.g. /proc (procfs), /sys (sysfs). The complete list of currently known pseudo filesystems is: binfmt, bpf, cgroup, cgroup2, debug, devpts, proc, pstore, security, selinux, sys, trace.")
-	flags.BoolVar(&exKernFlag, "exclude-kernfs", false, "(Linux only) Exclude Linux pseudo filesystems, e.g. /proc (procfs), /sys (sysfs). The complete list of currently known pseudo filesystems is: binfmt, bpf, cgroup, cgroup2, debug, devpts, proc, pstore, security, selinux, sys, trace.")
+	flags.StringVarP(&opts.OutputFile, "output-file", "o", "", "-o [FILE] defines file for data output")
+	flags.Var(formatValue{&opts.OutputFormat}, "output-format", "output-format [FORMAT]: Select the format written by -o. json (the default) can be read back with -f, html writes a standalone page with a zoomable sunburst chart of the tree and svg a static version of the same chart.")
+	flags.StringVarP(&opts.InputFile, "input-file", "f", "", "-f [FILE] defines file for data input")
+	flags.StringVar(&opts.TarFile, "tar", "", "--tar [FILE] sizes the content of the tar archive FILE, or of the one read from standard input if FILE is -, instead of scanning a directory, e.g. docker save IMAGE | godu --tar -. The archive may be compressed with gzip. Sizes, modes and modification times are taken from the tar headers and hard links take no space.")
+	flags.BoolVarP(&opts.Version, "version", "v", false, "-v shows the current version of godu")
+	addSwitch(flags, &scanOpts.Extended, true, "extended", "e", "-e enables extended information mode")
+	addSwitch(flags, &scanOpts.Extended, false, "no-extended", "", "disables extended information mode")
+	flags.Bool("ignore-config", false, "--ignore-config prevents godu from attempting to load any configuration files")
+	addSwitch(flags, &scanOpts.OneFileSystem, true, "one-file-system", "x", "-x prevents godu from crossing filesystem boundaries, i.e. only count files and directories on the same filesystem as the directory being scanned")
+	addSwitch(flags, &scanOpts.OneFileSystem, false, "cross-file-system", "", "--cross-file-system allows godu to cross filesystem boundaries. This is the default, but can be specified to overrule a previously given '-x'")
+	flags.StringArrayVar(&scanOpts.Exclude, "exclude", nil, "--exclude [PATTERN] excludes files that match PATTERN. The files will still be displayed by default, but are not counted towards the disk usage statistics. This argument can be added multiple times to add more patterns.")
+	flags.StringVarP(&opts.ExcludeFrom, "exclude-from", "X", "", "-X [FILE], --exclude-from [FILE] Exclude files that match any pattern in FILE. Patterns should be separated by a newline.")
+	addSwitch(flags, &scanOpts.FollowSymlinks, true, "follow-symlinks", "L", "-L, --follow-symlinks follows symlinks and counts the size of the file they point to.")
+	addSwitch(flags, &scanOpts.FollowSymlinks, false, "no-follow-symlinks", "", "does not follow symbolic links")
+	addSwitch(flags, &scanOpts.Archives, true, "archives", "", "--archives lists the content of tar and zip archives (.tar, .tar.gz, .tgz, .tar.bz2, .tbz2 and .zip) as read-only directories. Inside them, the disk usage of a file is its compressed size, estimated for compressed tar archives, and its apparent size the uncompressed one. Archives themselves still count with their size on disk.")
+	addSwitch(flags, &scanOpts.Archives, false, "no-archives", "", "lists archives as plain files. This is the default.")
+	addSwitch(flags, &scanOpts.ComputeHashes, true, "hash", "", "--hash reads the files that have the same size as another one during the scan, so that the duplicate files ('D') and identical folders ('F') reports open without reading them again. This makes the scan slower.")
+	addSwitch(flags, &scanOpts.ComputeHashes, false, "no-hash", "", "only reads files when the duplicate files or identical folders report is opened. This is the default.")
+	addSwitch(flags, &opts.NoCache, false, "cache", "", "--cache keeps the last scan of every directory in the user cache directory, e.g. ~/.cache/godu, so that the next scan of the same directory only lists again the directories whose modification or change time differs. In the others, every file is still checked, and the archive listings and content hashes of the unchanged ones are reused. This is the default, except with --older-than.")
+	addSwitch(flags, &opts.NoCache, true, "no-cache", "", "--no-cache scans every directory from scratch and replaces the cached scan with the new one.")
+	flags.Var(ageValue{&scanOpts.OlderThan}, "older-than", "older-than [DURATION]: Only count and list files that were last modified longer ago than DURATION, e.g. 90d, 2w, 1y or 36h. Directories are still listed, but their sizes only include the older files.")
+	addSwitch(flags, &scanOpts.ExcludeKernfs, false, "include-kernfs", "", "(Linux only) Include (default) Linux pseudo filesystems, e.g. /proc (procfs), /sys (sysfs). The complete list of currently known pseudo filesystems is: binfmt, bpf, cgroup, cgroup2, debug, devpts, proc, pstore, security, selinux, sys, trace.")
+	addSwitch(flags, &scanOpts.ExcludeKernfs, true, "exclude-kernfs", "", "(Linux only) Exclude Linux pseudo filesystems, e.g. /proc (procfs), /sys (sysfs). The complete list of currently known pseudo filesystems is: binfmt, bpf, cgroup, cgroup2, debug, devpts, proc, pstore, security, selinux, sys, trace.")
 	//interface option flags
-	flags.BoolVar(&zeroFlag, "0", true, "Don't give any feedback while scanning a directory or importing a file, other than when a fatal error occurs. This option is the default when exporting to standard output.")
-	flags.BoolVar(&oneFlag, "1", false, "Similar to -0, but does give feedback on the scanning progress with a single line of output. This option is the default when exporting to a file.")
-	flags.BoolVar(&twoFlag, "2", false, "Provide a full-screen ncurses interface while scanning a directory or importing a file. This is the only interface that provides feedback on any non-fatal errors while scanning.")
-	flags.BoolVar(&qFlag, "q", false, "Change the UI update interval while scanning or importing. This can be decreased to once every 2 seconds with -q or --slow-ui-updates. This feature can be used to save bandwidth over remote connections, but has no effect when -0 is used.")
-	flags.BoolVar(&fastFlag, "fast-ui-updates", false, "Change the UI update interval while scanning or importing to 10 times per second. This option has no effect when -0 is used.")
-	flags.BoolVar(&slowFlag, "slow-ui-updates", false, "Change the UI update interval while scanning or importing. This can be decreased to once every 2 seconds with -q or --slow-ui-updates. This feature can be used to save bandwidth over remote connections, but has no effect when -0 is used.")
-	flags.BoolVar(&eShellFlag, "enable-shell", true, "Enable shell spawning from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
-	flags.BoolVar(&dShellFlag, "disable-shell", false, "Disable shell spawning from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
-	flags.BoolVar(&eDeleteFlag, "enable-delete", true, "Enable the built-in file deletion feature. This feature is enabled by default when scanning a live directory and disabled when importing from file. Explicitly disabling the deletion feature can work as a safeguard to prevent accidental data loss.")
-	flags.BoolVar(&dDeleteFlag, "disable-delete", false, "Disable the built-in file deletion feature. This feature is enabled by default when scanning a live directory and disabled when importing from file. Explicitly disabling the deletion feature can work as a safeguard to prevent accidental data loss.")
-	flags.BoolVar(&eRefreshFlag, "enable-refresh", true, "Enable directory refreshing from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
-	flags.BoolVar(&dRefreshFlag, "disable-refresh", false, "Disable directory refreshing from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
-	flags.CountVar(&rFlag, "r", "Read-only mode. When given once, this is an alias for --disable-delete, when given twice it will also add --disable-shell, thus ensuring that there is no way to modify the file system from within godu.")
-	flags.BoolVar(&siFlag, "si", false, "List sizes using base 10 prefixes, that is, powers of 1000 (KB, MB, etc), as defined in the International System of Units (SI), instead of the usual base 2 prefixes, that is, powers of 1024 (KiB, MiB, etc).")
-	flags.BoolVar(&noSiFlag, "no-si", true, "List sizes using the usual base 2 prefixes, that is, powers of 1024 (KiB, MiB, etc).")
-	flags.BoolVar(&duFlag, "disk-usage", true, "Display disk usage (default). Can also be toggled to apparent size in the browser with the 'a' key.")
-	flags.BoolVar(&apFlag, "apparent-size", false, "Display apparent sizes. Can also be toggled to disk usage in the browser with the 'a' key.")
-	flags.BoolVar(&shFlag, "show-hidden", true, "Show (default) 'hidden' and excluded files. Can also be toggled in the browser with the 'e' key.")
-	flags.BoolVar(&hhFlag, "hide-hidden", false, "Hide 'hidden' and excluded files. Can also be toggled in the browser with the 'e' key.")
-	flags.BoolVar(&sicFlag, "show-itemcount", false, "Show the item counts column. Can also be toggled in the browser with the 'c' key.")
-	flags.BoolVar(&hicFlag, "hide-itemcount", true, "Hide (default) the item counts column. Can also be toggled in the browser with the 'c' key.")
-	flags.BoolVar(&smtFlag, "show-mtime", false, "Show the last modification time column. Can also be toggled in the browser with the 'm' key. This option is ignored when not in extended mode (see -e).")
-	flags.BoolVar(&hmtFlag, "hide-mtime", true, "Hide (default) the last modification time column. Can also be toggled in the browser with the 'm' key. This option is ignored when not in extended mode (see -e).")
-	flags.BoolVar(&sgFlag, "show-graph", true, "Show (default) the relative size bar column. Can also be toggled in the browser with the 'g' key.")
-	flags.BoolVar(&hgFlag, "hide-graph", false, "Hide the relative size bar column. Can also be toggled in the browser with the 'g' key.")
-	flags.BoolVar(&spFlag, "show-percent", true, "Show (default) the relative size percent column. Can also be toggled in the browser with the 'g' key.")
-	flags.BoolVar(&hpFlag, "hide-percent", false, "Hide the relative size percent column. Can also be toggled in the browser with the 'g' key.")
-	flags.StringVar(&gStyleFlag, "graph-style", "", "graph-style [OPTION]: Change the way that the relative size bar column is drawn. Recognized values are hash to draw ASCII # characters (default and most portable), half-block to use half-block drawing characters or eighth-block to use eighth-block drawing characters. Eighth-block characters are the most precise but may not render correctly in all terminals.")
-	flags.StringVar(&sColumnFlag, "shared-column", "shared", "shared-column [OPTION]: Set to off to disable the shared size column for directories, shared (default) to display shared directory sizes as a separate column or unique to display unique directory sizes as a separate column. These options can also be cycled through in the browser with the 'u' key.")
-	flags.StringVar(&sortFlag, "sort", "disk-usage", "sort [COLUMN]: Change the default column to sort on. Accepted values are disk-usage (the default), name, apparent-size, itemcount or mtime. The latter only makes sense in extended mode, see -e. The column can be suffixed with -asc or -desc to set the order to ascending or descending, respectively. e.g. --sort=name-desc will sort by name in descending order.")
-	flags.BoolVar(&gdFlag, "group-directories-first", true, "Sort (default) directories before files.")
-	flags.BoolVar(&ngdFlag, "no-group-directories-first", false, "Don't sort directories before files.")
-	flags.BoolVar(&cqFlag, "confirm-quit", true, "Require a confirmation before quitting ncdu. Very helpful when you accidentally press 'q' during or after a very long scan.")
-	flags.BoolVar(&cdFlag, "confirm-delete", true, "Require a confirmation before deleting a file or directory. Enabled by default, but can be disabled if you're absolutely sure you won't accidentally press 'd'.")
-	flags.StringVar(&colorFlag, "color", "", "color [SCHEME]: Select a color scheme. The following schemes are recognized: off to disable colors, dark for a color scheme intended for dark backgrounds and dark-bg for a variation of the dark color scheme that also works in terminals with a light background. The default is dark-bg unless the NO_COLOR environment variable is set.")
+	addChoice(flags, &opts.Interface, silentInterface, "0", "0", "Don't give any feedback while scanning a directory or importing a file, other than when a fatal error occurs. This option is the default when exporting to standard output.")
+	addChoice(flags, &opts.Interface, progressInterface, "1", "1", "Similar to -0, but does give feedback on the scanning progress with a single line of output. This option is the default when exporting to a file.")
+	addChoice(flags, &opts.Interface, fullInterface, "2", "2", "Provide a full-screen ncurses interface while scanning a directory or importing a file. This is the only interface that provides feedback on any non-fatal errors while scanning.")
+	addChoice(flags, &opts.UIUpdates, fastUpdates, "fast-ui-updates", "", "Change the UI update interval while scanning or importing to 10 times per second. This option has no effect when -0 is used.")
+	addChoice(flags, &opts.UIUpdates, slowUpdates, "slow-ui-updates", "q", "Change the UI update interval while scanning or importing. This can be decreased to once every 2 seconds with -q or --slow-ui-updates. This feature can be used to save bandwidth over remote connections, but has no effect when -0 is used.")
+	addSwitch(flags, &ui.EnableShell, true, "enable-shell", "", "Enable shell spawning from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
+	addSwitch(flags, &ui.EnableShell, false, "disable-shell", "", "Disable shell spawning from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
+	addSwitch(flags, &ui.EnableDelete, true, "enable-delete", "", "Enable the built-in file deletion feature. This feature is enabled by default when scanning a live directory and disabled when importing from file. Explicitly disabling the deletion feature can work as a safeguard to prevent accidental data loss.")
+	addSwitch(flags, &ui.EnableDelete, false, "disable-delete", "", "Disable the built-in file deletion feature. This feature is enabled by default when scanning a live directory and disabled when importing from file. Explicitly disabling the deletion feature can work as a safeguard to prevent accidental data loss.")
+	addSwitch(flags, &ui.EnableRefresh, true, "enable-refresh", "", "Enable directory refreshing from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
+	addSwitch(flags, &ui.EnableRefresh, false, "disable-refresh", "", "Disable directory refreshing from the browser. This feature is enabled by default when scanning a live directory and disabled when importing from file.")
+	addSwitch(flags, &ui.Watch, true, "watch", "", "(Linux only) Keep the browser up to date with the files created, changed, removed or moved after the scan, using inotify. When there are more directories than the inotify watch limit allows (see fs.inotify.max_user_watches), the ones past the limit are not kept up to date. This has no effect when importing from file or with --tar.")
+	addSwitch(flags, &ui.Watch, false, "no-watch", "", "Don't watch for changes after the scan (default).")
+	r := flags.VarPF(&readOnlyValue{opts: ui}, "read-only", "r", "Read-only mode. When given once, this is an alias for --disable-delete, when given twice it will also add --disable-shell, thus ensuring that there is no way to modify the file system from within godu.")
+	r.NoOptDefVal = "+1"
+	addSwitch(flags, &ui.SI, true, "si", "", "List sizes using base 10 prefixes, that is, powers of 1000 (KB, MB, etc), as defined in the International System of Units (SI), instead of the usual base 2 prefixes, that is, powers of 1024 (KiB, MiB, etc).")
+	addSwitch(flags, &ui.SI, false, "no-si", "", "List sizes using the usual base 2 prefixes, that is, powers of 1024 (KiB, MiB, etc).")
+	addSwitch(flags, &ui.UseApparentSize, false, "disk-usage", "", "Display disk usage (default). Can also be toggled to apparent size in the browser with the 'a' key.")
+	addSwitch(flags, &ui.UseApparentSize, true, "apparent-size", "", "Display apparent sizes. Can also be toggled to disk usage in the browser with the 'a' key.")
+	addSwitch(flags, &ui.ShowHidden, true, "show-hidden", "", "Show (default) 'hidden' and excluded files. Can also be toggled in the browser with the 'e' key.")
+	addSwitch(flags, &ui.ShowHidden, false, "hide-hidden", "", "Hide 'hidden' and excluded files. Can also be toggled in the browser with the 'e' key.")
+	addSwitch(flags, &ui.ShowItemCount, true, "show-itemcount", "", "Show the item counts column. Can also be toggled in the browser with the 'c' key.")
+	addSwitch(flags, &ui.ShowItemCount, false, "hide-itemcount", "", "Hide (default) the item counts column. Can also be toggled in the browser with the 'c' key.")
+	addSwitch(flags, &ui.ShowMTime, true, "show-mtime", "", "Show the last modification time column. Can also be toggled in the browser with the 'm' key. This option is ignored when not in extended mode (see -e).")
+	addSwitch(flags, &ui.ShowMTime, false, "hide-mtime", "", "Hide (default) the last modification time column. Can also be toggled in the browser with the 'm' key. This option is ignored when not in extended mode (see -e).")
+	addSwitch(flags, &ui.ShowGraph, true, "show-graph", "", "Show (default) the relative size bar column. Can also be toggled in the browser with the 'g' key.")
+	addSwitch(flags, &ui.ShowGraph, false, "hide-graph", "", "Hide the relative size bar column. Can also be toggled in the browser with the 'g' key.")
+	addSwitch(flags, &ui.ShowPercent, true, "show-percent", "", "Show (default) the relative size percent column. Can also be toggled in the browser with the 'g' key.")
+	addSwitch(flags, &ui.ShowPercent, false, "hide-percent", "", "Hide the relative size percent column. Can also be toggled in the browser with the 'g' key.")
+	flags.Var(graphStyleValue{&ui.GraphStyle}, "graph-style", "graph-style [OPTION]: Change the way that the relative size bar column is drawn. Recognized values are hash to draw ASCII # characters (default and most portable), half-block to use half-block drawing characters or eighth-block to use eighth-block drawing characters. Eighth-block characters are the most precise but may not render correctly in all terminals.")
+	flags.Var(sharedColumnValue{&ui.SharedColumn}, "shared-column", "shared-column [OPTION]: Set to off to disable the shared size column for directories, shared (default) to display shared directory sizes as a separate column or unique to display unique directory sizes as a separate column. These options can also be cycled through in the browser with the 'u' key.")
+	flags.Var(&sortValue{opts: ui, value: "disk-usage"}, "sort", "sort [COLUMN]: Change the default column to sort on. Accepted values are disk-usage (the default), name, apparent-size, itemcount or mtime. The latter only makes sense in extended mode, see -e. The column can be suffixed with -asc or -desc to set the order to ascending or descending, respectively. e.g. --sort=name-desc will sort by name in descending order.")
+	addSwitch(flags, &ui.DirectoryFirst, true, "group-directories-first", "", "Sort (default) directories before files.")
+	addSwitch(flags, &ui.DirectoryFirst, false, "no-group-directories-first", "", "Don't sort directories before files.")
+	addSwitch(flags, &ui.ConfirmQuit, true, "confirm-quit", "", "Require a confirmation before quitting ncdu. Very helpful when you accidentally press 'q' during or after a very long scan.")
+	addSwitch(flags, &ui.ConfirmQuit, false, "no-confirm-quit", "", "Don't ask for confirmation before quitting.")
+	addSwitch(flags, &ui.ConfirmDelete, true, "confirm-delete", "", "Require a confirmation before deleting a file or directory. Enabled by default, but can be disabled if you're absolutely sure you won't accidentally press 'd'.")
+	addSwitch(flags, &ui.ConfirmDelete, false, "no-confirm-delete", "", "Don't ask for confirmation before deleting a file or directory.")
+	flags.Var(bindingValue{&ui.KeyBindings}, "bind", "bind [ACTION=KEY,...]: Bind the browser action ACTION to the given keys instead of its default ones, e.g. --bind delete=x,delete. This argument can be added multiple times to rebind more actions, and is mostly useful in a configuration file.")
+	flags.Var(colorValue{&ui.Color}, "color", "color [SCHEME]: Select a color scheme. The following schemes are recognized: off to disable colors, dark for a color scheme intended for dark backgrounds and dark-bg for a variation of the dark color scheme that also works in terminals with a light background. The default is dark-bg unless the NO_COLOR environment variable is set.")
 }
 
-func main() {
-	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+// run scans dir, or imports the input file, and then either exports the
+// result or starts the browser on it.
+func run(opts Options, dir string) error {
+	if opts.OutputFile != "" {
+		return exportTree(opts, dir)
+	}
+	tree, err := load(opts, dir)
+	if err != nil {
+		return err
 	}
 
-	hidden := true
-	defaultOrdering := tui.Size
-	directoryFirst := true
-	desc := true
+	initialModel := tui.Model{
+		Tree:    tree,
+		Current: tree.Root(),
+		Options: opts.UI,
+		Version: godu_version,
+		Scan:    opts.Scan,
+	}
 
-	root, err := du.CreateFileTree(dir)
+	model, err := tui.NewModel(initialModel)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
-
-	/* Marshal it!
-	jsn, err := json.MarshalIndent(root, "", " ")
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	final, err := p.StartReturningModel()
 	if err != nil {
-		return
+		return err
 	}
-	fmt.Println(string(jsn))*/
+	if path := final.(tui.Model).Exported; path != "" {
+		fmt.Printf("Exported to %s\n", path)
+	}
+	return nil
+}
 
-	/*files, err := du.ListFilesRecursivelyInParallel(dir)
-	if err != nil {
-		log.Fatalln(err)
-	}*/
+// load builds the tree, giving feedback according to the -0/-1/-2 mode.
+func load(opts Options, dir string) (*scan.Tree, error) {
+	if opts.InputFile != "" {
+		root, err := importTree(opts.InputFile)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Scan.OlderThan > 0 {
+			root = scan.FilterOlderThan(root, time.Now().Add(-opts.Scan.OlderThan))
+		}
+		return scan.FromFolder(root), nil
+	}
 
-	/*
-		type Model struct {
-			// This section is for maintaining the `du` content
-			CurrentFolder Folder
-			Root          Folder
-			TotalSz       int64
+	progress := &scan.Progress{}
+	opts.Scan.Progress = progress
+	scanTree := func() (*scan.Tree, error) {
+		return scan.ScanTree(dir, opts.Scan)
+	}
+	if opts.TarFile != "" {
+		scanTree = func() (*scan.Tree, error) {
+			return scanTar(opts.TarFile, opts.Scan)
+		}
+	}
 
-			// other options
-			ListOrder      Order
-			Descending     bool
-			ShowHidden     bool
-			DirectoryFirst bool
+	switch opts.interfaceMode() {
+	case progressInterface:
+		stop := startProgressLine(os.Stderr, progress, opts.updateInterval())
+		defer stop()
+	case fullInterface:
+		return tui.Scan(scanTree, progress, opts.updateInterval())
+	}
+	return scanTree()
+}
+
+// importTree reads a tree from name, or from standard input if name is "-".
+func importTree(name string) (scan.Folder, error) {
+	if name == "-" {
+		return scan.Import(os.Stdin)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return scan.Folder{}, fmt.Errorf("error opening input file: %w", err)
+	}
+	defer file.Close()
+	return scan.Import(file)
+}
 
-			// the rest is for actually maintaining the TUI display
-			list         list.Model
-			keys         *listKeyMap
-			delegateKeys *delegateKeyMap
-			Version      string
+// scanTar lists the tar archive name, or the one read from standard input if
+// name is "-".
+func scanTar(name string, opts scan.ScanOptions) (*scan.Tree, error) {
+	r, label := io.Reader(os.Stdin), "<stdin>"
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("error opening tar file: %w", err)
 		}
-	*/
+		defer file.Close()
+		r, label = file, name
+	}
+	return scan.ScanTar(r, label, opts)
+}
 
-	startingStack := make([]du.Folder, 0)
+// exportTree scans dir, or imports the input file, and writes the result to
+// the -o file, or to standard output if it is "-", in the format selected
+// with --output-format. The file is created before scanning, so that a path
+// that can't be written is reported at once rather than after a long scan.
+func exportTree(opts Options, dir string) (err error) {
+	if opts.OutputFile == "-" {
+		t, err := load(opts, dir)
+		if err != nil {
+			return err
+		}
+		return writeTree(os.Stdout, t, opts)
+	}
+	file, err := os.OpenFile(opts.OutputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error setting output file: %w", err)
+	}
+	defer func() {
+		cerr := file.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+	t, err := load(opts, dir)
+	if err != nil {
+		return err
+	}
+	return writeTree(file, t, opts)
+}
 
-	initialModel := tui.Model{
-		CurrentFolder:  root,
-		Root:           root,
-		Stack:          startingStack,
-		ShowHidden:     hidden,
-		ListOrder:      defaultOrdering,
-		Descending:     desc,
-		DirectoryFirst: directoryFirst,
-		Version:        godu_version,
+// writeTree writes t to w. The export formats work on a Folder, so the tree
+// is only copied into one here.
+func writeTree(w io.Writer, t *scan.Tree, opts Options) error {
+	root := t.Folder(t.Root())
+	chart := scan.ChartOptions{
+		Title:        "godu " + godu_version,
+		ApparentSize: opts.UI.UseApparentSize,
+		MinFraction:  chartMinFraction,
 	}
+	switch opts.OutputFormat {
+	case "html":
+		return scan.ExportHTML(w, root, chart)
+	case "svg":
+		return scan.ExportSVG(w, root, chart)
+	}
+	return scan.Export(w, root, "godu "+godu_version)
+}
 
-	p := tea.NewProgram(tui.NewModel(initialModel), tea.WithAltScreen())
-	if err := p.Start(); err != nil {
+func main() {
+	args, err := buildArgs(os.Args[1:])
+	if err != nil {
 		log.Fatal(err)
 	}
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
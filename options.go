@@ -0,0 +1,363 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"internal/tui"
+
+	"github.com/davleop/godu/scan"
+	"github.com/spf13/pflag"
+)
+
+// Interface selects how much feedback godu gives while scanning.
+const (
+	silentInterface   = 0
+	progressInterface = 1
+	fullInterface     = 2
+	// unsetInterface picks one of the above depending on the output file.
+	unsetInterface = -1
+)
+
+// UI update rates while scanning or importing.
+const (
+	normalUpdates = iota
+	fastUpdates
+	slowUpdates
+)
+
+// Options is everything that can be set from the command line and the
+// configuration files.
+type Options struct {
+	Scan scan.ScanOptions
+	UI   tui.Options
+
+	Version     bool
+	InputFile   string
+	TarFile     string
+	NoCache     bool
+	OutputFile  string
+	ExcludeFrom string
+	Interface   int
+	UIUpdates   int
+	// OutputFormat is the format -o writes, see outputFormats.
+	OutputFormat string
+}
+
+func defaultOptions() Options {
+	opts := Options{
+		UI:           tui.DefaultOptions(),
+		Interface:    unsetInterface,
+		OutputFormat: "json",
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		opts.UI.Color = tui.ColorOff
+	}
+	return opts
+}
+
+/*
+Most of ncdu's options come in pairs such as --extended/--no-extended, and the
+one given last has to win, no matter whether it came from a configuration file
+or the command line. pflag has no notion of order across different flags, so
+instead of resolving the pairs after parsing, every flag of a pair writes
+straight into the same field of Options while it is being parsed.
+*/
+
+// switchValue is a boolean flag that sets target to on when given.
+type switchValue struct {
+	target *bool
+	on     bool
+}
+
+func (s *switchValue) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*s.target = b == s.on
+	return nil
+}
+
+func (s *switchValue) String() string   { return strconv.FormatBool(*s.target == s.on) }
+func (s *switchValue) Type() string     { return "bool" }
+func (s *switchValue) IsBoolFlag() bool { return true }
+
+// addSwitch registers a flag that sets target to on.
+func addSwitch(flags *pflag.FlagSet, target *bool, on bool, name, shorthand, usage string) {
+	f := flags.VarPF(&switchValue{target: target, on: on}, name, shorthand, usage)
+	f.NoOptDefVal = "true"
+}
+
+// choiceValue is a boolean flag that stores value in target when given, for
+// groups of mutually exclusive flags such as -0, -1 and -2.
+type choiceValue struct {
+	target *int
+	value  int
+}
+
+func (c *choiceValue) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if b {
+		*c.target = c.value
+	}
+	return nil
+}
+
+func (c *choiceValue) String() string   { return strconv.FormatBool(*c.target == c.value) }
+func (c *choiceValue) Type() string     { return "bool" }
+func (c *choiceValue) IsBoolFlag() bool { return true }
+
+func addChoice(flags *pflag.FlagSet, target *int, value int, name, shorthand, usage string) {
+	f := flags.VarPF(&choiceValue{target: target, value: value}, name, shorthand, usage)
+	f.NoOptDefVal = "true"
+}
+
+// readOnlyValue implements -r, which disables deletion when given once and
+// shell spawning as well when given twice.
+type readOnlyValue struct {
+	opts  *tui.Options
+	count int
+}
+
+func (r *readOnlyValue) Set(value string) error {
+	r.count++
+	r.opts.EnableDelete = false
+	if r.count > 1 {
+		r.opts.EnableShell = false
+	}
+	return nil
+}
+
+func (r *readOnlyValue) String() string { return strconv.Itoa(r.count) }
+func (r *readOnlyValue) Type() string   { return "count" }
+
+// sortValue validates --sort while parsing.
+type sortValue struct {
+	opts  *tui.Options
+	value string
+}
+
+func (s *sortValue) Set(value string) error {
+	order, desc, err := tui.ParseSort(value)
+	if err != nil {
+		return err
+	}
+	s.opts.ListOrder, s.opts.Descending, s.value = order, desc, value
+	return nil
+}
+
+func (s *sortValue) String() string { return s.value }
+func (s *sortValue) Type() string   { return "column" }
+
+type graphStyleValue struct{ target *tui.GraphStyle }
+
+func (g graphStyleValue) Set(value string) (err error) {
+	*g.target, err = tui.ParseGraphStyle(value)
+	return
+}
+
+func (g graphStyleValue) String() string { return g.target.String() }
+func (g graphStyleValue) Type() string   { return "style" }
+
+type colorValue struct{ target *tui.ColorScheme }
+
+func (c colorValue) Set(value string) (err error) {
+	*c.target, err = tui.ParseColorScheme(value)
+	return
+}
+
+func (c colorValue) String() string { return c.target.String() }
+func (c colorValue) Type() string   { return "scheme" }
+
+type sharedColumnValue struct{ target *tui.SharedColumn }
+
+func (s sharedColumnValue) Set(value string) (err error) {
+	*s.target, err = tui.ParseSharedColumn(value)
+	return
+}
+
+func (s sharedColumnValue) String() string { return s.target.String() }
+func (s sharedColumnValue) Type() string   { return "option" }
+
+// bindingValue collects --bind ACTION=KEY,... values.
+type bindingValue struct{ target *map[string][]string }
+
+func (b bindingValue) Set(value string) error {
+	action, keys, err := tui.ParseBinding(value)
+	if err != nil {
+		return err
+	}
+	if *b.target == nil {
+		*b.target = map[string][]string{}
+	}
+	(*b.target)[action] = keys
+	return nil
+}
+
+func (b bindingValue) String() string {
+	bindings := []string{}
+	for action, keys := range *b.target {
+		bindings = append(bindings, action+"="+strings.Join(keys, ","))
+	}
+	sort.Strings(bindings)
+	return strings.Join(bindings, " ")
+}
+
+func (b bindingValue) Type() string { return "binding" }
+
+// outputFormats are the formats -o can write. Only json can be read back
+// with -f.
+var outputFormats = []string{"json", "html", "svg"}
+
+// formatValue validates --output-format while parsing.
+type formatValue struct{ target *string }
+
+func (f formatValue) Set(value string) error {
+	for _, format := range outputFormats {
+		if value == format {
+			*f.target = value
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", value, strings.Join(outputFormats, ", "))
+}
+
+func (f formatValue) String() string { return *f.target }
+func (f formatValue) Type() string   { return "format" }
+
+// ageValue parses --older-than. On top of Go durations such as 36h it takes
+// a number of days, weeks or years, e.g. 90d, 2w or 1y.
+type ageValue struct{ target *time.Duration }
+
+func (a ageValue) Set(value string) error {
+	d, err := parseAge(value)
+	if err != nil {
+		return err
+	}
+	*a.target = d
+	return nil
+}
+
+func (a ageValue) String() string {
+	if *a.target == 0 {
+		return ""
+	}
+	return a.target.String()
+}
+
+func (a ageValue) Type() string { return "duration" }
+
+func parseAge(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+		'y': 365 * 24 * time.Hour,
+	}
+	if len(s) > 1 {
+		if unit, ok := units[s[len(s)-1]]; ok {
+			n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// interfaceMode resolves the -0/-1/-2 default like ncdu does: silent when
+// exporting to standard output, a progress line when exporting to a file and
+// the full interface otherwise.
+func (o Options) interfaceMode() int {
+	if o.Interface != unsetInterface {
+		return o.Interface
+	}
+	switch o.OutputFile {
+	case "":
+		return fullInterface
+	case "-":
+		return silentInterface
+	}
+	return progressInterface
+}
+
+// updateInterval returns how often scan progress is redrawn.
+func (o Options) updateInterval() time.Duration {
+	switch o.UIUpdates {
+	case fastUpdates:
+		return time.Second / 10
+	case slowUpdates:
+		return 2 * time.Second
+	}
+	return time.Second
+}
+
+// prepare reads the --exclude-from file and validates the options once
+// they have all been parsed.
+func (o *Options) prepare() error {
+	if o.ExcludeFrom != "" {
+		patterns, err := readPatterns(o.ExcludeFrom)
+		if err != nil {
+			return err
+		}
+		o.Scan.Exclude = append(o.Scan.Exclude, patterns...)
+	}
+	// extended mode is needed by both halves
+	o.UI.Extended = o.Scan.Extended
+	o.Scan.CacheDir = cacheDir()
+	o.Scan.RefreshCache = o.NoCache
+	return o.validate()
+}
+
+// cacheDir returns where scans are cached, or "" if there is no place for
+// it.
+func cacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "godu")
+}
+
+// validate checks the combinations of options that can't be rejected while
+// parsing a single flag.
+func (o Options) validate() error {
+	if o.InputFile != "" && o.InputFile == o.OutputFile && o.InputFile != "-" {
+		return fmt.Errorf("input and output file are the same: %s", o.InputFile)
+	}
+	if o.InputFile != "" && o.TarFile != "" {
+		return fmt.Errorf("--input-file and --tar can't be used together")
+	}
+	return nil
+}
+
+// applyImportDefaults disables watching, and shell spawning, deletion and
+// refreshing unless they were explicitly asked for, when browsing an imported
+// file or a tar archive.
+func (o *Options) applyImportDefaults(flags *pflag.FlagSet) {
+	// there is nothing on disk to watch
+	o.UI.Watch = false
+	features := map[string]*bool{
+		"shell":   &o.UI.EnableShell,
+		"delete":  &o.UI.EnableDelete,
+		"refresh": &o.UI.EnableRefresh,
+	}
+	for name, enabled := range features {
+		if !flags.Changed("enable-"+name) && !flags.Changed("disable-"+name) {
+			*enabled = false
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"internal/tui"
+
+	"github.com/spf13/pflag"
+)
+
+// newFlags returns a flag set on its own, since the flags of rootCmd remember
+// which of them were given and how often -r was.
+func newFlags() *pflag.FlagSet {
+	return pflag.NewFlagSet("godu", pflag.ContinueOnError)
+}
+
+func TestSwitchLastWins(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"--enable-x"}, true},
+		{[]string{"--disable-x"}, false},
+		{[]string{"--enable-x", "--disable-x"}, false},
+		{[]string{"--disable-x", "--enable-x"}, true},
+		{[]string{"--enable-x", "--disable-x", "--enable-x"}, true},
+		{[]string{"--enable-x=false"}, false},
+		{[]string{"--disable-x=false"}, true},
+	}
+	for _, tt := range tests {
+		flags := newFlags()
+		var enabled bool
+		addSwitch(flags, &enabled, true, "enable-x", "", "")
+		addSwitch(flags, &enabled, false, "disable-x", "", "")
+		if err := flags.Parse(tt.args); err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if enabled != tt.want {
+			t.Errorf("%v: enabled = %v, want %v", tt.args, enabled, tt.want)
+		}
+	}
+}
+
+func TestChoice(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{nil, unsetInterface, false},
+		{[]string{"-0"}, silentInterface, false},
+		{[]string{"-0", "-2"}, fullInterface, false},
+		{[]string{"-2", "-1"}, progressInterface, false},
+		{[]string{"-2", "--1=false"}, fullInterface, false},
+		{[]string{"--1=maybe"}, unsetInterface, true},
+		{[]string{"--2=full"}, unsetInterface, true},
+	}
+	for _, tt := range tests {
+		flags := newFlags()
+		mode := unsetInterface
+		addChoice(flags, &mode, silentInterface, "0", "0", "")
+		addChoice(flags, &mode, progressInterface, "1", "1", "")
+		addChoice(flags, &mode, fullInterface, "2", "2", "")
+		err := flags.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: error = %v, want one: %v", tt.args, err, tt.wantErr)
+		}
+		if mode != tt.want {
+			t.Errorf("%v: mode = %d, want %d", tt.args, mode, tt.want)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		args                []string
+		enableDelete, shell bool
+	}{
+		{nil, true, true},
+		{[]string{"-r"}, false, true},
+		{[]string{"-r", "-r"}, false, false},
+		{[]string{"-rr"}, false, false},
+		{[]string{"--read-only", "-r", "-r"}, false, false},
+	}
+	for _, tt := range tests {
+		flags := newFlags()
+		ui := tui.DefaultOptions()
+		r := flags.VarPF(&readOnlyValue{opts: &ui}, "read-only", "r", "")
+		r.NoOptDefVal = "+1"
+		if err := flags.Parse(tt.args); err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if ui.EnableDelete != tt.enableDelete || ui.EnableShell != tt.shell {
+			t.Errorf("%v: EnableDelete, EnableShell = %v, %v, want %v, %v",
+				tt.args, ui.EnableDelete, ui.EnableShell, tt.enableDelete, tt.shell)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90d", 90 * day, false},
+		{"2w", 14 * day, false},
+		{"1y", 365 * day, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"0d", 0, false},
+		{"36h", 36 * time.Hour, false},
+		{"1h30m", 90 * time.Minute, false},
+		// m is minutes, as in Go durations
+		{"1m", time.Minute, false},
+		{"", 0, true},
+		{"d", 0, true},
+		{"xd", 0, true},
+		{"-1d", 0, true},
+		{"-5h", 0, true},
+		{"10", 0, true},
+		{"10x", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAge(%q) error = %v, want one: %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyImportDefaults(t *testing.T) {
+	tests := []struct {
+		args                   []string
+		shell, delete, refresh bool
+	}{
+		{nil, false, false, false},
+		{[]string{"--enable-delete"}, false, true, false},
+		{[]string{"--enable-shell", "--enable-refresh"}, true, false, true},
+		{[]string{"--enable-delete", "--disable-delete", "--disable-shell"}, false, false, false},
+		{[]string{"--disable-refresh", "--enable-refresh"}, false, false, true},
+	}
+	for _, tt := range tests {
+		flags := newFlags()
+		o := defaultOptions()
+		o.UI.Watch = true
+		for name, target := range map[string]*bool{
+			"shell":   &o.UI.EnableShell,
+			"delete":  &o.UI.EnableDelete,
+			"refresh": &o.UI.EnableRefresh,
+		} {
+			addSwitch(flags, target, true, "enable-"+name, "", "")
+			addSwitch(flags, target, false, "disable-"+name, "", "")
+		}
+		if err := flags.Parse(tt.args); err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		o.applyImportDefaults(flags)
+		name := strings.Join(tt.args, " ")
+		if o.UI.Watch {
+			t.Errorf("%q: still watching", name)
+		}
+		if o.UI.EnableShell != tt.shell || o.UI.EnableDelete != tt.delete || o.UI.EnableRefresh != tt.refresh {
+			t.Errorf("%q: shell, delete, refresh = %v, %v, %v, want %v, %v, %v", name,
+				o.UI.EnableShell, o.UI.EnableDelete, o.UI.EnableRefresh, tt.shell, tt.delete, tt.refresh)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/davleop/godu/scan"
+)
+
+// progressWidth is the width the -1 progress line is padded and truncated to
+// so that a shorter line fully overwrites a longer one.
+const progressWidth = 79
+
+// startProgressLine prints the progress of a scan as a single line to w every
+// interval, like ncdu's -1 interface. The returned function stops printing
+// and finishes the line.
+func startProgressLine(w io.Writer, p *scan.Progress, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintf(w, "\r%s", formatProgress(p))
+			case <-done:
+				fmt.Fprintf(w, "\r%s\n", formatProgress(p))
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+func formatProgress(p *scan.Progress) string {
+	line := fmt.Sprintf("%8s %8d items", scan.PrettyPrintSize(p.Size()), p.Items())
+	if errors := p.Errors(); errors > 0 {
+		line += fmt.Sprintf(" %d errors", errors)
+	}
+	line += " | "
+
+	// keep the end of the path, it's the interesting part
+	path := []rune(p.Path())
+	if room := progressWidth - len([]rune(line)); len(path) > room && room > 3 {
+		path = append([]rune("..."), path[len(path)-room+3:]...)
+	}
+	line += string(path)
+	return fmt.Sprintf("%-*s", progressWidth, line)
+}
@@ -0,0 +1,145 @@
+package scan
+
+import "time"
+
+const day = 24 * time.Hour
+
+// ageLimits are the upper bounds of the buckets of AgeHistogram. The last
+// bucket has no upper bound.
+var ageLimits = []struct {
+	label string
+	limit time.Duration
+}{
+	{"< 30 days", 30 * day},
+	{"30 - 90 days", 90 * day},
+	{"90 days - 1 year", 365 * day},
+	{"> 1 year", 0},
+}
+
+// AgeBucket is the files whose age falls in [Min, Max). Max is 0 for the
+// last bucket.
+type AgeBucket struct {
+	Label        string
+	Min          time.Duration
+	Max          time.Duration
+	Size         int64
+	ApparentSize int64
+	Files        []Entry
+}
+
+// AgeHistogram sorts the files below folder into buckets by how long ago
+// they were last modified, or accessed with useAccessTime. Files without an
+// access time, which is only recorded in extended mode, are left out in that
+// case. Excluded files are skipped.
+func AgeHistogram(folder Folder, now time.Time, useAccessTime bool) []AgeBucket {
+	t := FromFolder(folder)
+	return t.AgeHistogram(t.Root(), now, useAccessTime)
+}
+
+// AgeHistogram sorts the files below the folder id into buckets by age, like
+// AgeHistogram does for a Folder.
+func (t *Tree) AgeHistogram(id NodeID, now time.Time, useAccessTime bool) []AgeBucket {
+	buckets := make([]AgeBucket, len(ageLimits))
+	var min time.Duration
+	for i, l := range ageLimits {
+		buckets[i] = AgeBucket{Label: l.label, Min: min, Max: l.limit, Files: []Entry{}}
+		min = l.limit
+	}
+
+	t.walkBelow(id, func(c NodeID) bool {
+		if t.Excluded(c) {
+			return false
+		}
+		if t.IsDir(c) {
+			return true
+		}
+		when := t.ModTime(c)
+		if useAccessTime {
+			if when = t.AccessTime(c); when.IsZero() {
+				return true
+			}
+		}
+		age := now.Sub(when)
+		i := 0
+		for i < len(buckets)-1 && age >= buckets[i].Max {
+			i++
+		}
+		b := &buckets[i]
+		b.Size += t.Size(c)
+		b.ApparentSize += t.ApparentSize(c)
+		b.Files = append(b.Files, t.Entry(c))
+		return true
+	})
+	return buckets
+}
+
+// FilterOlderThan returns folder with only the files last modified before
+// cutoff, and its totals counting only those. Folders are kept even when
+// nothing is left in them. Like in a scan with ScanOptions.OlderThan, an
+// archive listed as a folder is left out or kept as a whole by its own
+// modification time, and keeps its own size whatever is left of its content.
+// folder is not modified.
+func FilterOlderThan(folder Folder, cutoff time.Time) Folder {
+	out := folder
+	out.Items = 0
+	if !folder.Archive {
+		out.Size, out.ApparentSize = ownSize(folder)
+	}
+	add := func(size, apparent, items int64) {
+		if !folder.Archive {
+			out.Size += size
+			out.ApparentSize += apparent
+		}
+		out.Items += items
+	}
+
+	out.Files = make([]File, 0, len(folder.Files))
+	for _, f := range folder.Files {
+		if f.ModTime.After(cutoff) {
+			continue
+		}
+		out.Files = append(out.Files, f)
+		if !f.Excluded {
+			add(f.Size, f.ApparentSize, 1)
+		}
+	}
+	out.Folders = make([]Folder, 0, len(folder.Folders))
+	for _, f := range folder.Folders {
+		if f.Archive && f.ModTime.After(cutoff) {
+			continue
+		}
+		f = FilterOlderThan(f, cutoff)
+		out.Folders = append(out.Folders, f)
+		if !f.Excluded {
+			add(f.Size, f.ApparentSize, f.Items+1)
+		}
+	}
+	out.HumanSize = PrettyPrintSize(out.Size)
+	return out
+}
+
+// ownSize returns the size of the directory entry of folder itself, which
+// is what its totals add to the ones of its content. An imported file may
+// have totals that don't add up, so it is never less than 0.
+func ownSize(folder Folder) (size, apparent int64) {
+	size, apparent = folder.Size, folder.ApparentSize
+	for _, f := range folder.Files {
+		if !f.Excluded {
+			size -= f.Size
+			apparent -= f.ApparentSize
+		}
+	}
+	for _, f := range folder.Folders {
+		if !f.Excluded {
+			size -= f.Size
+			apparent -= f.ApparentSize
+		}
+	}
+	if size < 0 {
+		size = 0
+	}
+	if apparent < 0 {
+		apparent = 0
+	}
+	return size, apparent
+}
@@ -0,0 +1,95 @@
+package scan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FilterOlderThan on the Folder of a whole scan gives the same totals as a
+// scan with OlderThan, archives included.
+func TestFilterOlderThan(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"old.txt":     "old",
+		"new.txt":     "new",
+		"sub/old.txt": "older",
+		"sub/new.txt": "newer",
+	})
+	// the content of the archive adds up to much more than the archive
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	writeTar(t, gz, []archived{{"a.txt", strings.Repeat("a", 10000)}, {"b.txt", strings.Repeat("b", 10000)}})
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"old.tar.gz", "sub/new.tar.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-365 * 24 * time.Hour)
+	for _, name := range []string{"old.txt", "sub/old.txt", "old.tar.gz"} {
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	age := 30 * 24 * time.Hour
+	tree, err := ScanTree(dir, ScanOptions{Archives: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := FilterOlderThan(tree.Folder(tree.Root()), time.Now().Add(-age))
+	want, err := ScanTree(dir, ScanOptions{Archives: true, OlderThan: age})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := want.Root()
+	if got.Size != want.Size(root) || got.ApparentSize != want.ApparentSize(root) || got.Items != want.Items(root) {
+		t.Errorf("totals = %d, %d, %d items, want %d, %d, %d items",
+			got.Size, got.ApparentSize, got.Items, want.Size(root), want.ApparentSize(root), want.Items(root))
+	}
+
+	var archive *Folder
+	for i, f := range got.Folders {
+		switch f.Name {
+		case "old.tar.gz":
+			archive = &got.Folders[i]
+		case "sub":
+			for _, g := range f.Folders {
+				if g.Name == "new.tar.gz" {
+					t.Error("the new archive is still listed")
+				}
+			}
+		}
+	}
+	if archive == nil {
+		t.Fatal("the old archive isn't listed")
+	}
+	if archive.ApparentSize != int64(buf.Len()) || archive.Items != 2 {
+		t.Errorf("old.tar.gz = %d bytes, %d items, want its own %d bytes and 2 items",
+			archive.ApparentSize, archive.Items, buf.Len())
+	}
+}
+
+// Imported totals that don't add up leave folders empty rather than with a
+// negative size.
+func TestFilterOlderThanNegative(t *testing.T) {
+	old := time.Now().Add(-365 * 24 * time.Hour)
+	folder := Folder{
+		Name: "root", Size: 100, ApparentSize: 100,
+		Files: []File{
+			{Name: "old", Size: 300, ApparentSize: 300, ModTime: old},
+			{Name: "new", Size: 500, ApparentSize: 500, ModTime: time.Now()},
+		},
+	}
+	got := FilterOlderThan(folder, time.Now().Add(-time.Hour))
+	if got.Size != 300 || got.ApparentSize != 300 || got.Items != 1 {
+		t.Errorf("totals = %d, %d, %d items, want only the old file", got.Size, got.ApparentSize, got.Items)
+	}
+}
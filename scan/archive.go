@@ -0,0 +1,287 @@
+package scan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveKind returns how the archive called name is read, or "" if it isn't
+// one godu can read.
+func archiveKind(name string) string {
+	name = strings.ToLower(name)
+	for _, kind := range []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2"} {
+		if strings.HasSuffix(name, kind) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// openArchive lists the content of the archive at name in fsys, of kind and
+// size bytes on disk.
+func openArchive(fsys fs.FS, name, kind string, size int64) (*memFS, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if kind == ".zip" {
+		r, ok := f.(io.ReaderAt)
+		if !ok {
+			return nil, fmt.Errorf("error reading %s: not seekable", name)
+		}
+		return readZip(r, size)
+	}
+	var r io.Reader = f
+	switch kind {
+	case ".tar.gz", ".tgz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	case ".tar.bz2", ".tbz2":
+		r = bzip2.NewReader(f)
+	}
+	m, err := readTar(r)
+	if err != nil {
+		return nil, err
+	}
+	if kind != ".tar" {
+		m.spread(size)
+	}
+	return m, nil
+}
+
+// ScanTar lists the tar archive read from r, which may be compressed with
+// gzip, into a Tree whose root is called name, e.g. to size a container image
+// layer piped from docker save. The archive is read from the tar headers
+// only, so hard links take no space and the whole of it is marked as archive
+// content.
+func ScanTar(r io.Reader, name string, opts ScanOptions) (*Tree, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+	m, err := readTar(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &scanner{opts: opts, fsys: m, archive: name}
+	s.opts.OneFileSystem = false
+	if opts.OlderThan > 0 {
+		s.cutoff = time.Now().Add(-opts.OlderThan)
+	}
+	t := NewTree(name)
+	// the content of the files isn't kept, so nothing can read it
+	t.fsys = m
+	t.setInfo(t.Root(), m.entries["."])
+	t.nodes[t.Root()].flags |= nodeArchive
+	s.scanDir(t, t.Root(), ".", NoNode)
+	return t, nil
+}
+
+// readZip lists a zip archive. The disk usage of its files is their
+// compressed size.
+func readZip(r io.ReaderAt, size int64) (*memFS, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	m := newMemFS()
+	for _, f := range z.File {
+		info := f.FileInfo()
+		m.add(f.Name, info.Mode(), info.Size(), f.Modified, Stat{DiskUsage: int64(f.CompressedSize64)})
+	}
+	return m, nil
+}
+
+// readTar lists the tar archive read from r, without keeping the content of
+// its files. Hard links take no space of their own.
+func readTar(r io.Reader) (*memFS, error) {
+	tr := tar.NewReader(r)
+	m := newMemFS()
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading tar archive: %w", err)
+		}
+		info := h.FileInfo()
+		size := info.Size()
+		if h.Typeflag == tar.TypeLink {
+			size = 0
+		}
+		m.add(h.Name, info.Mode(), size, h.ModTime, Stat{
+			DiskUsage:  size,
+			UID:        uint32(h.Uid),
+			GID:        uint32(h.Gid),
+			AccessTime: h.AccessTime,
+		})
+	}
+}
+
+// memFS is a read-only filesystem made of the entries listed in an archive.
+// Only their details are kept, so the files can't be read.
+type memFS struct {
+	entries map[string]*memEntry
+}
+
+// memEntry is a file or directory of a memFS.
+type memEntry struct {
+	name     string
+	mode     fs.FileMode
+	size     int64
+	modTime  time.Time
+	stat     Stat
+	children []*memEntry
+}
+
+func (e *memEntry) Name() string               { return e.name }
+func (e *memEntry) Size() int64                { return e.size }
+func (e *memEntry) Mode() fs.FileMode          { return e.mode }
+func (e *memEntry) ModTime() time.Time         { return e.modTime }
+func (e *memEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *memEntry) Sys() interface{}           { return &e.stat }
+func (e *memEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *memEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+func newMemFS() *memFS {
+	root := &memEntry{name: ".", mode: fs.ModeDir | 0755}
+	return &memFS{entries: map[string]*memEntry{".": root}}
+}
+
+// add records an entry, creating the directories above it that the archive
+// doesn't list itself. Entries listed twice keep the last details, except
+// that an entry with others below it stays a directory, see dir.
+func (m *memFS) add(name string, mode fs.FileMode, size int64, modTime time.Time, stat Stat) {
+	name = path.Clean("/" + name)[1:]
+	if name == "" {
+		return
+	}
+	e := m.dir(path.Dir(name))
+	if old, ok := m.entries[name]; ok {
+		if len(old.children) > 0 && !mode.IsDir() {
+			return
+		}
+		old.mode, old.size, old.modTime, old.stat = mode, size, modTime, stat
+		return
+	}
+	entry := &memEntry{name: path.Base(name), mode: mode, size: size, modTime: modTime, stat: stat}
+	m.entries[name] = entry
+	e.children = append(e.children, entry)
+}
+
+// dir returns the directory called name, creating it if needed. An archive
+// may list a file and then entries below it of the same name, in which case
+// the file becomes a directory so that they aren't lost.
+func (m *memFS) dir(name string) *memEntry {
+	if name == "" {
+		name = "."
+	}
+	if e, ok := m.entries[name]; ok {
+		if !e.IsDir() {
+			e.mode, e.size = fs.ModeDir|0755, 0
+			e.stat.DiskUsage = 0
+		}
+		return e
+	}
+	parent := m.dir(path.Dir(name))
+	e := &memEntry{name: path.Base(name), mode: fs.ModeDir | 0755}
+	m.entries[name] = e
+	parent.children = append(parent.children, e)
+	return e
+}
+
+// spread shares the size of a compressed archive among its files in
+// proportion to their size, since the compressed size of each file isn't
+// known.
+func (m *memFS) spread(size int64) {
+	var total int64
+	for _, e := range m.entries {
+		if e.mode.IsRegular() {
+			total += e.size
+		}
+	}
+	if total == 0 {
+		return
+	}
+	for _, e := range m.entries {
+		if e.mode.IsRegular() {
+			e.stat.DiskUsage = int64(float64(e.size) / float64(total) * float64(size))
+		}
+	}
+}
+
+func (m *memFS) lookup(op, name string) (*memEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := m.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return e, nil
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	e, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{e}, nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	return m.lookup("stat", name)
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	entries := make([]fs.DirEntry, 0, len(e.children))
+	for _, c := range e.children {
+		entries = append(entries, c)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// memFile is an opened entry of a memFS.
+type memFile struct {
+	*memEntry
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.memEntry, nil }
+func (f *memFile) Close() error               { return nil }
+func (f *memFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("content of archived files isn't kept")}
+}
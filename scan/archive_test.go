@@ -0,0 +1,261 @@
+package scan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// archived is an entry written to a test archive. Names ending with a slash
+// are directories.
+type archived struct {
+	name string
+	data string
+}
+
+// writeTar writes entries to w as a tar archive.
+func writeTar(t *testing.T, w io.Writer, entries []archived) {
+	t.Helper()
+	tw := tar.NewWriter(w)
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.data)), ModTime: when, Uid: 1000, Gid: 100}
+		if strings.HasSuffix(e.name, "/") {
+			h.Typeflag, h.Mode = tar.TypeDir, 0755
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// archiveTree scans dir with archives listed, and returns the entry of the
+// archive called name in it.
+func archiveTree(t *testing.T, dir, name string) (*Tree, NodeID) {
+	t.Helper()
+	tree, err := ScanTree(dir, ScanOptions{Archives: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, ok := tree.Lookup(filepath.Join(dir, name))
+	if !ok {
+		t.Fatalf("%s not found", name)
+	}
+	if !tree.IsArchive(id) || !tree.IsDir(id) {
+		t.Fatalf("%s isn't listed as an archive", name)
+	}
+	return tree, id
+}
+
+// archivedFile returns the entry at name inside the archive id, which has to
+// be archive content.
+func archivedFile(t *testing.T, tree *Tree, id NodeID, name string) NodeID {
+	t.Helper()
+	c, ok := tree.Lookup(filepath.Join(tree.Path(id), name))
+	if !ok {
+		t.Fatalf("%s not found in %s", name, tree.Name(id))
+	}
+	if !tree.InArchive(c) {
+		t.Errorf("%s isn't marked as archive content", name)
+	}
+	return c
+}
+
+func TestReadZip(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []archived{
+		{"docs/", ""},
+		{"docs/a.txt", strings.Repeat("a", 1000)},
+		{"b.txt", "not much to compress"},
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "x.zip"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed := map[string]int64{}
+	for _, f := range z.File {
+		compressed[f.Name] = int64(f.CompressedSize64)
+	}
+
+	tree, id := archiveTree(t, dir, "x.zip")
+	if got := tree.Items(id); got != 3 {
+		t.Errorf("Items = %d, want 3", got)
+	}
+	if got := tree.ApparentSize(id); got != int64(buf.Len()) {
+		t.Errorf("ApparentSize of the archive = %d, want its own size %d", got, buf.Len())
+	}
+	for _, f := range files[1:] {
+		c := archivedFile(t, tree, id, f.name)
+		if got := tree.ApparentSize(c); got != int64(len(f.data)) {
+			t.Errorf("ApparentSize of %s = %d, want %d", f.name, got, len(f.data))
+		}
+		if got := tree.Size(c); got != compressed[f.name] {
+			t.Errorf("Size of %s = %d, want its compressed size %d", f.name, got, compressed[f.name])
+		}
+	}
+	if docs := archivedFile(t, tree, id, "docs"); !tree.IsDir(docs) {
+		t.Error("docs isn't a directory")
+	}
+}
+
+func TestReadTarGz(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	files := []archived{
+		// a.txt is listed without its directory
+		{"sub/a.txt", strings.Repeat("a", 3000)},
+		{"b.txt", strings.Repeat("b", 1000)},
+	}
+	writeTar(t, gz, files)
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	size := int64(buf.Len())
+	if err := os.WriteFile(filepath.Join(dir, "x.tar.gz"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, id := archiveTree(t, dir, "x.tar.gz")
+	if got := tree.Items(id); got != 3 {
+		t.Errorf("Items = %d, want 3", got)
+	}
+	a := archivedFile(t, tree, id, "sub/a.txt")
+	b := archivedFile(t, tree, id, "b.txt")
+	if got := tree.ApparentSize(a); got != 3000 {
+		t.Errorf("ApparentSize of a.txt = %d, want 3000", got)
+	}
+	// the size of the archive is shared in proportion to the file sizes
+	if got, want := tree.Size(a), size*3/4; got < want-1 || got > want {
+		t.Errorf("Size of a.txt = %d, want 3/4 of %d", got, size)
+	}
+	if got, want := tree.Size(b), size/4; got < want-1 || got > want {
+		t.Errorf("Size of b.txt = %d, want 1/4 of %d", got, size)
+	}
+	if got := tree.UID(b); got != 1000 {
+		t.Errorf("UID of b.txt = %d, want the one from the header", got)
+	}
+}
+
+func TestMemFSFileThenDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []archived
+	}{
+		{"file first", []archived{{"a", "file"}, {"a/b", "below"}}},
+		{"file last", []archived{{"a/b", "below"}, {"a", "file"}}},
+		{"nested", []archived{{"a", "file"}, {"a/c/b", "below"}}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeTar(t, &buf, tt.entries)
+		m, err := readTar(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := m.entries["a"]
+		if !a.IsDir() || a.Size() != 0 || a.stat.DiskUsage != 0 {
+			t.Errorf("%s: a = %v, %d bytes, want an empty directory", tt.name, a.Mode(), a.Size())
+		}
+
+		tree, err := ScanTree(".", ScanOptions{FS: m})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tree.ApparentSize(tree.Root()); got != int64(len("below")) {
+			t.Errorf("%s: ApparentSize = %d, want only the file below a", tt.name, got)
+		}
+		if id, _ := tree.Lookup("a"); tree.nodes[id].flags&nodeUnread != 0 {
+			t.Errorf("%s: a is marked as unread", tt.name)
+		}
+	}
+}
+
+func TestScanTar(t *testing.T) {
+	files := []archived{
+		{"etc/", ""},
+		{"etc/hosts", "localhost"},
+		{"usr/bin/sh", strings.Repeat("x", 100)},
+	}
+	var plain, compressed bytes.Buffer
+	writeTar(t, &plain, files)
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(plain.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"tar": plain.Bytes(), "tar.gz": compressed.Bytes()} {
+		tree, err := ScanTar(bytes.NewReader(data), "stdin", ScanOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		root := tree.Root()
+		if !tree.IsArchive(root) || !tree.IsDir(root) || tree.Name(root) != "stdin" {
+			t.Errorf("%s: the root isn't an archive called stdin", name)
+		}
+		if got := tree.Items(root); got != 5 {
+			t.Errorf("%s: Items = %d, want 5", name, got)
+		}
+		if got := tree.ApparentSize(root); got != int64(len("localhost")+100) {
+			t.Errorf("%s: ApparentSize = %d, want %d", name, got, len("localhost")+100)
+		}
+		tree.walkBelow(root, func(c NodeID) bool {
+			if !tree.InArchive(c) {
+				t.Errorf("%s: %s isn't marked as archive content", name, tree.Path(c))
+			}
+			return true
+		})
+
+		hosts, ok := tree.Lookup("stdin/etc/hosts")
+		if !ok {
+			t.Fatalf("%s: etc/hosts not found", name)
+		}
+		for _, id := range []NodeID{hosts, root} {
+			if err := tree.Delete(id); err == nil {
+				t.Errorf("%s: deleting %s succeeded", name, tree.Path(id))
+			}
+		}
+		if !tree.Contains(hosts) {
+			t.Errorf("%s: etc/hosts was removed from the tree", name)
+		}
+	}
+}
+
+func TestScanTarCorrupt(t *testing.T) {
+	if _, err := ScanTar(strings.NewReader(strings.Repeat("not a tar archive", 100)), "stdin", ScanOptions{}); err == nil {
+		t.Error("scanning garbage succeeded")
+	}
+}
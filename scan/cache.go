@@ -0,0 +1,175 @@
+package scan
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion is bumped whenever the layout of the cache files changes, so
+// that older ones are ignored.
+const cacheVersion = 1
+
+// cacheBatch is how many nodes are encoded at a time, to keep the memory
+// needed to write or read a large cache down.
+const cacheBatch = 4096
+
+// cacheHeader starts a cache file and is followed by the nodes of the tree in
+// batches.
+type cacheHeader struct {
+	Version int
+	Root    string
+	Options string
+	Names   []string
+	Nodes   int
+}
+
+// cacheNode is a node with exported fields so that gob can encode it.
+type cacheNode struct {
+	Name                                       uint32
+	Parent, FirstChild, LastChild, NextSibling NodeID
+	Flags                                      uint8
+	Mode                                       os.FileMode
+	UID, GID                                   uint32
+	Size, Apparent, Items                      int64
+	ModTime, ChangeTime, AccessTime            int64
+	Hash                                       uint64
+}
+
+// cacheFile returns the file caching the scans of dir.
+func cacheFile(opts ScanOptions, dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	h := fnv.New64a()
+	io.WriteString(h, dir)
+	return filepath.Join(opts.CacheDir, fmt.Sprintf("%016x.cache", h.Sum64()))
+}
+
+// cacheOptions describes the options that change what a scan finds, which
+// have to be the same for a cached scan to be used.
+func cacheOptions(opts ScanOptions) string {
+	return fmt.Sprintf("%t %t %t %t %t %q", opts.FollowSymlinks, opts.OneFileSystem,
+		opts.ExcludeKernfs, opts.Extended, opts.Archives, opts.Exclude)
+}
+
+// readCache returns the cached scan of dir, or nil if there is none that can
+// be used.
+func readCache(opts ScanOptions, dir string) *Tree {
+	file, err := os.Open(cacheFile(opts, dir))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil
+	}
+	t, err := decodeTree(gob.NewDecoder(zr), dir, cacheOptions(opts))
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// decodeTree reads a cached scan of dir made with options from dec.
+func decodeTree(dec *gob.Decoder, dir, options string) (*Tree, error) {
+	var h cacheHeader
+	if err := dec.Decode(&h); err != nil {
+		return nil, err
+	}
+	if h.Version != cacheVersion || h.Root != dir || h.Options != options || h.Nodes < 1 {
+		return nil, errors.New("cache doesn't match the scan")
+	}
+
+	t := &Tree{names: h.Names, nameIndex: make(map[string]uint32, len(h.Names))}
+	for i, name := range h.Names {
+		t.nameIndex[name] = uint32(i)
+	}
+	t.nodes = make([]node, 0, h.Nodes)
+	valid := func(id NodeID) bool { return id >= NoNode && int(id) < h.Nodes }
+	for len(t.nodes) < h.Nodes {
+		var batch []cacheNode
+		if err := dec.Decode(&batch); err != nil {
+			return nil, err
+		}
+		for _, c := range batch {
+			if int(c.Name) >= len(t.names) || !valid(c.Parent) || !valid(c.FirstChild) ||
+				!valid(c.LastChild) || !valid(c.NextSibling) {
+				return nil, errors.New("corrupt cache")
+			}
+			t.nodes = append(t.nodes, node{
+				name: c.Name, parent: c.Parent, firstChild: c.FirstChild,
+				lastChild: c.LastChild, nextSibling: c.NextSibling, flags: c.Flags,
+				mode: c.Mode, uid: c.UID, gid: c.GID, size: c.Size,
+				apparent: c.Apparent, items: c.Items, modTime: c.ModTime,
+				changeTime: c.ChangeTime, accessTime: c.AccessTime, hash: c.Hash,
+			})
+		}
+	}
+	if len(t.nodes) != h.Nodes || t.Name(t.Root()) != dir {
+		return nil, errors.New("corrupt cache")
+	}
+	return t, nil
+}
+
+// writeCache saves t as the cached scan of dir. The file is replaced at
+// once, so that a scan never reads a partly written one.
+func writeCache(opts ScanOptions, dir string, t *Tree) error {
+	if err := os.MkdirAll(opts.CacheDir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(opts.CacheDir, "scan-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	zw, err := gzip.NewWriterLevel(tmp, gzip.BestSpeed)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := encodeTree(gob.NewEncoder(zw), dir, cacheOptions(opts), t); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cacheFile(opts, dir))
+}
+
+// encodeTree writes t, a scan of dir made with options, to enc.
+func encodeTree(enc *gob.Encoder, dir, options string, t *Tree) error {
+	h := cacheHeader{Version: cacheVersion, Root: dir, Options: options, Names: t.names, Nodes: len(t.nodes)}
+	if err := enc.Encode(h); err != nil {
+		return err
+	}
+	batch := make([]cacheNode, 0, cacheBatch)
+	for i, n := range t.nodes {
+		batch = append(batch, cacheNode{
+			Name: n.name, Parent: n.parent, FirstChild: n.firstChild,
+			LastChild: n.lastChild, NextSibling: n.nextSibling, Flags: n.flags,
+			Mode: n.mode, UID: n.uid, GID: n.gid, Size: n.size,
+			Apparent: n.apparent, Items: n.items, ModTime: n.modTime,
+			ChangeTime: n.changeTime, AccessTime: n.accessTime, Hash: n.hash,
+		})
+		if len(batch) == cacheBatch || i == len(t.nodes)-1 {
+			if err := enc.Encode(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	return nil
+}
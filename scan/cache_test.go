@@ -0,0 +1,186 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates the files of content below dir, along with their
+// folders.
+func writeFiles(t *testing.T, dir string, content map[string]string) {
+	t.Helper()
+	for name, data := range content {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// cachedScan scans dir with opts, which set CacheDir, and checks that the
+// scan was cached.
+func cachedScan(t *testing.T, dir string, opts ScanOptions) *Tree {
+	t.Helper()
+	tree, err := ScanTree(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readCache(opts, dir) == nil {
+		t.Fatal("the scan wasn't cached")
+	}
+	return tree
+}
+
+// wantSize checks the apparent size of the entry at name below dir.
+func wantSize(t *testing.T, tree *Tree, dir, name string, size int64) {
+	t.Helper()
+	id, ok := tree.Lookup(filepath.Join(dir, name))
+	if !ok {
+		t.Fatalf("%s not found", name)
+	}
+	if got := tree.ApparentSize(id); got != size {
+		t.Errorf("size of %s = %d, want %d", name, got, size)
+	}
+}
+
+// wantFresh checks the totals of tree against a scan of dir without cache.
+func wantFresh(t *testing.T, tree *Tree, dir string, opts ScanOptions) {
+	t.Helper()
+	opts.CacheDir = ""
+	fresh, err := ScanTree(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want := tree.Root(), fresh.Root()
+	if tree.Size(got) != fresh.Size(want) || tree.ApparentSize(got) != fresh.ApparentSize(want) ||
+		tree.Items(got) != fresh.Items(want) {
+		t.Errorf("totals = %d, %d, %d items, want %d, %d, %d items",
+			tree.Size(got), tree.ApparentSize(got), tree.Items(got),
+			fresh.Size(want), fresh.ApparentSize(want), fresh.Items(want))
+	}
+}
+
+func TestCacheFileChangedInPlace(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "small", "sub/b": "small", "sub/c": "kept"})
+	opts := ScanOptions{CacheDir: t.TempDir()}
+	cachedScan(t, dir, opts)
+
+	// rewriting files doesn't change their folders
+	writeFiles(t, dir, map[string]string{"a": "much larger", "sub/b": "larger"})
+	tree := cachedScan(t, dir, opts)
+	wantSize(t, tree, dir, "a", 11)
+	wantSize(t, tree, dir, "sub/b", 6)
+	wantSize(t, tree, dir, "sub/c", 4)
+	wantFresh(t, tree, dir, opts)
+}
+
+func TestCacheFileAddedAndRemoved(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"sub/a": "one", "sub/b": "two"})
+	opts := ScanOptions{CacheDir: t.TempDir()}
+	cachedScan(t, dir, opts)
+
+	if err := os.Remove(filepath.Join(dir, "sub", "a")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"sub/c": "three"})
+	tree := cachedScan(t, dir, opts)
+	if _, ok := tree.Lookup(filepath.Join(dir, "sub", "a")); ok {
+		t.Error("removed file is still listed")
+	}
+	wantSize(t, tree, dir, "sub/c", 5)
+	wantFresh(t, tree, dir, opts)
+}
+
+func TestCacheDirectoryRenamed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"old/deep/a": "one", "other/b": "two"})
+	opts := ScanOptions{CacheDir: t.TempDir()}
+	cachedScan(t, dir, opts)
+
+	if err := os.Rename(filepath.Join(dir, "old"), filepath.Join(dir, "new")); err != nil {
+		t.Fatal(err)
+	}
+	tree := cachedScan(t, dir, opts)
+	if _, ok := tree.Lookup(filepath.Join(dir, "old")); ok {
+		t.Error("renamed folder is still listed under its old name")
+	}
+	wantSize(t, tree, dir, "new/deep/a", 3)
+	wantSize(t, tree, dir, "other/b", 3)
+	wantFresh(t, tree, dir, opts)
+}
+
+func TestCacheOptionsChanged(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"sub/a.log": "log", "sub/b": "kept"})
+	cacheDir := t.TempDir()
+	cachedScan(t, dir, ScanOptions{CacheDir: cacheDir})
+
+	opts := ScanOptions{CacheDir: cacheDir, Exclude: []string{"*.log"}}
+	if readCache(opts, dir) != nil {
+		t.Fatal("the cache of a scan with other options was used")
+	}
+	tree := cachedScan(t, dir, opts)
+	id, _ := tree.Lookup(filepath.Join(dir, "sub", "a.log"))
+	if !tree.Excluded(id) {
+		t.Error("a.log isn't excluded")
+	}
+	wantFresh(t, tree, dir, opts)
+}
+
+func TestCacheCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "one", "sub/b": "two"})
+	opts := ScanOptions{CacheDir: t.TempDir()}
+	cachedScan(t, dir, opts)
+	file := cacheFile(opts, dir)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string][]byte{
+		"truncated": data[:len(data)/2],
+		"garbage":   []byte("not a cache"),
+		"empty":     nil,
+	} {
+		if err := os.WriteFile(file, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if readCache(opts, dir) != nil {
+			t.Errorf("%s cache was read", name)
+		}
+		tree := cachedScan(t, dir, opts)
+		wantSize(t, tree, dir, "a", 3)
+		wantSize(t, tree, dir, "sub/b", 3)
+		wantFresh(t, tree, dir, opts)
+	}
+}
+
+func TestRefreshCache(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "one"})
+	opts := ScanOptions{CacheDir: t.TempDir()}
+	cachedScan(t, dir, opts)
+
+	// a cached scan that no longer matches the disk is neither used nor kept
+	cached := readCache(opts, dir)
+	a, _ := cached.Lookup(filepath.Join(dir, "a"))
+	cached.nodes[a].apparent = 100
+	if err := writeCache(opts, dir, cached); err != nil {
+		t.Fatal(err)
+	}
+	opts.RefreshCache = true
+	tree := cachedScan(t, dir, opts)
+	wantSize(t, tree, dir, "a", 3)
+	cached = readCache(opts, dir)
+	a, _ = cached.Lookup(filepath.Join(dir, "a"))
+	if got := cached.ApparentSize(a); got != 3 {
+		t.Errorf("cached size of a = %d, want 3", got)
+	}
+}
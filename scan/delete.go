@@ -0,0 +1,32 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+)
+
+// Delete removes the entry id from disk and from t. Folders are removed
+// along with everything inside them. Trees scanned from ScanOptions.FS or
+// from a tar stream aren't on the local disk, so nothing can be deleted from
+// them.
+func (t *Tree) Delete(id NodeID) error {
+	path := t.Path(id)
+	if t.Parent(id) == NoNode {
+		return fmt.Errorf("error deleting %s: can't delete the scanned folder", path)
+	}
+	if t.InArchive(id) {
+		return fmt.Errorf("error deleting %s: can't delete inside an archive", path)
+	}
+	if !t.Local() {
+		return fmt.Errorf("error deleting %s: not scanned from the local disk", path)
+	}
+	if t.IsDir(id) {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("error deleting folder: %w", err)
+		}
+	} else if err := os.Remove(path); err != nil {
+		return fmt.Errorf("error deleting file: %w", err)
+	}
+	t.Remove(id)
+	return nil
+}
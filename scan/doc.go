@@ -0,0 +1,37 @@
+/*
+Package scan is the disk usage scanner behind godu, for use in other Go
+programs.
+
+ScanTree walks a directory and returns a Tree, a compact store of every file
+and folder below it addressed by NodeID:
+
+	t, err := scan.ScanTree("/srv", scan.ScanOptions{OneFileSystem: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, id := range t.Children(t.Root()) {
+		fmt.Println(t.HumanSize(id), t.Name(id))
+	}
+
+ScanOptions.FS scans any io/fs filesystem instead of the local disk, such as
+an fstest.MapFS fixture in a test, and Stat lets it report disk usage,
+inodes and owners. ScanTar lists a tar stream, such as a container image
+layer, from its headers alone. ScanOptions.CacheDir keeps every scan on disk
+so that the next one only lists the folders that changed since, and only
+checks the files of the others. A Watcher
+reports what changes on disk afterwards, and Tree.Update applies each change
+to the tree.
+
+Tree.Walk visits a whole subtree, and the reports, such as Tree.Largest,
+Tree.Search or Tree.AgeHistogram, work on any folder of a Tree. Tree.Files
+takes the list of files out of a tree for the reports that read them, so
+that they can run in the background while the tree is in use. CreateFileTree
+returns the same scan as a Folder instead, a nested value that is easier to
+work with for small trees, which the same reports take as well. Tree.Folder
+and FromFolder convert between the two and WalkFiles visits every file of a
+Folder.
+
+Export and Import write and read the JSON format of godu -o and godu -f, and
+ExportHTML and ExportSVG draw a Folder as a sunburst chart.
+*/
+package scan
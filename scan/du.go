@@ -0,0 +1,537 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// File is the object that contains the info and path of the file
+type File struct {
+	Path         string
+	HighDir      string
+	Name         string
+	Size         int64
+	ApparentSize int64
+	HumanSize    string
+	Mode         os.FileMode
+	ModTime      time.Time
+	// AccessTime is only recorded in extended mode.
+	AccessTime time.Time
+	UID        uint32
+	GID        uint32
+	Hash       uint64 `hash:"ignore"`
+	Excluded   bool
+	// InArchive is set for the content of archives, which isn't on disk.
+	InArchive bool `json:",omitempty"`
+}
+
+// Folder is a directory along with everything below it. Size, ApparentSize
+// and Items are totals over the whole subtree.
+type Folder struct {
+	Path         string
+	HighDir      string
+	Name         string
+	Size         int64
+	ApparentSize int64
+	HumanSize    string
+	Mode         os.FileMode
+	ModTime      time.Time
+	UID          uint32
+	GID          uint32
+	Hash         uint64 `hash:"ignore"`
+	Excluded     bool
+	// Archive is set for archive files listed as folders, and InArchive for
+	// their content.
+	Archive   bool `json:",omitempty"`
+	InArchive bool `json:",omitempty"`
+	Items     int64
+	Files     []File
+	Folders   []Folder
+}
+
+type NameSorter []File
+
+func (a NameSorter) Len() int           { return len(a) }
+func (a NameSorter) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a NameSorter) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+type TimeSorter []File
+
+func (a TimeSorter) Len() int           { return len(a) }
+func (a TimeSorter) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a TimeSorter) Less(i, j int) bool { return a[i].ModTime.Before(a[j].ModTime) }
+
+// PrettyPrintSizeSI is PrettyPrintSize using powers of 1000.
+func PrettyPrintSizeSI(size int64) string {
+	switch {
+	case size > 1000*1000*1000:
+		return fmt.Sprintf("%.1fG", float64(size)/(1000*1000*1000))
+	case size > 1000*1000:
+		return fmt.Sprintf("%.1fM", float64(size)/(1000*1000))
+	case size > 1000:
+		return fmt.Sprintf("%.1fK", float64(size)/1000)
+	default:
+		return fmt.Sprintf("%d", size)
+	}
+}
+
+func PrettyPrintSize(size int64) string {
+	switch {
+	case size > 1024*1024*1024:
+		return fmt.Sprintf("%.1fG", float64(size)/(1024*1024*1024))
+	case size > 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(size)/(1024*1024))
+	case size > 1024:
+		return fmt.Sprintf("%.1fK", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d", size)
+	}
+}
+
+// TODO(david): properly handle errors
+
+// ScanOptions controls what CreateFileTree counts while scanning.
+type ScanOptions struct {
+	// FollowSymlinks counts the size of the file a symlink points to instead
+	// of the link itself. Symlinks to directories are never followed.
+	FollowSymlinks bool
+	// OneFileSystem stops the scan from crossing filesystem boundaries.
+	OneFileSystem bool
+	// ExcludeKernfs skips Linux pseudo filesystems such as /proc and /sys.
+	ExcludeKernfs bool
+	// Extended records the additional information shown in extended mode.
+	Extended bool
+	// Exclude holds shell patterns matched against names and full paths.
+	// Excluded entries are kept in the tree but are not counted.
+	Exclude []string
+	// OlderThan, if not 0, leaves out the files modified more recently than
+	// that. Folders are kept, but only count the older files.
+	OlderThan time.Duration
+	// ComputeHashes makes the scan hash the content of every file that has
+	// the same size as another one, see FindDuplicates, and give every folder
+	// a hash of its content, see IdenticalFolders.
+	ComputeHashes bool
+	// Progress, if not nil, is updated as the scan goes.
+	Progress *Progress
+	// Archives lists the content of tar and zip archives as if they were
+	// folders. The archives still count with their size on disk.
+	Archives bool
+	// FS, if not nil, is scanned instead of the local disk, e.g. an
+	// fstest.MapFS or the content of an archive. The directory to scan is
+	// then a name in FS, such as ".", and FS can give the details fs.FileInfo
+	// lacks by returning a *Stat from its Sys method. The content of the
+	// files, for hashes and types, is then read from FS as well.
+	FS fs.FS
+	// CacheDir, if not empty, keeps the last scan of every directory in a
+	// file there. The next scan of the same directory with the same options
+	// only lists again the folders whose modification or change time differs
+	// from the cached one. The files of the others are still looked at one by
+	// one, and only the ones with the same size, times and mode are copied
+	// from the cache, along with their archive listing and content hash. It
+	// isn't used with FS or OlderThan.
+	CacheDir string
+	// RefreshCache scans everything again instead of using the cached scan,
+	// which is then replaced by the new one.
+	RefreshCache bool
+}
+
+// osFS reads the local disk. Unlike os.DirFS it takes native paths, absolute
+// or relative to the working directory, which io/fs doesn't allow.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+
+type scanner struct {
+	opts   ScanOptions
+	fsys   fs.FS
+	local  bool
+	device uint64
+	cutoff time.Time
+	// archive is the path of the archive being listed, which the names in
+	// fsys are relative to.
+	archive string
+	// prev is the cached scan of the same directory, if any.
+	prev *Tree
+}
+
+// path returns the full path of the entry called name in s.fsys.
+func (s *scanner) path(name string) string {
+	if s.archive == "" {
+		return name
+	}
+	return path.Join(s.archive, name)
+}
+
+// add adds an entry to the folder id of t, marking it as archive content
+// when listing an archive.
+func (s *scanner) add(t *Tree, id NodeID, name string, dir bool) NodeID {
+	c := t.add(id, name, dir)
+	if s.archive != "" {
+		t.nodes[c].flags |= nodeInArchive
+	}
+	return c
+}
+
+// excluded reports whether the entry at path matches one of the exclude
+// patterns.
+func (s *scanner) excluded(path, name string) bool {
+	for _, pattern := range s.opts.Exclude {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDir reports whether the directory at path should be listed without
+// being descended into.
+func (s *scanner) skipDir(path string, info fs.FileInfo) bool {
+	if s.opts.OneFileSystem {
+		if dev, ok := deviceID(info); ok && dev != s.device {
+			return true
+		}
+	}
+	if s.local && s.opts.ExcludeKernfs && isKernfs(path) {
+		return true
+	}
+	return false
+}
+
+// CreateFileTree scans dir and returns it as a Folder, see ScanTree.
+func CreateFileTree(dir string, opts ScanOptions) (root Folder, err error) {
+	t, err := ScanTree(dir, opts)
+	if err != nil {
+		return
+	}
+	return t.Folder(t.Root()), nil
+}
+
+// ScanTree scans dir and everything below it into a Tree.
+func ScanTree(dir string, opts ScanOptions) (*Tree, error) {
+	s := &scanner{opts: opts, fsys: opts.FS}
+	if s.fsys == nil {
+		s.fsys, s.local = osFS{}, true
+		dir = filepath.Clean(dir)
+	} else {
+		dir = path.Clean(dir)
+	}
+	info, err := fs.Stat(s.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s.device, _ = deviceID(info)
+	if opts.OlderThan > 0 {
+		s.cutoff = time.Now().Add(-opts.OlderThan)
+	}
+	cached := s.local && opts.CacheDir != "" && opts.OlderThan == 0
+	pid := NoNode
+	if cached && !opts.RefreshCache {
+		if s.prev = readCache(opts, dir); s.prev != nil {
+			pid = s.prev.Root()
+		}
+	}
+	t := NewTree(dir)
+	t.fsys = opts.FS
+	t.setInfo(t.Root(), info)
+	if err := s.scanDir(t, t.Root(), dir, pid); err != nil {
+		return nil, err
+	}
+	if opts.ComputeHashes {
+		t.FindIdenticalFolders(t.Root(), runtime.NumCPU())
+	}
+	if cached {
+		// the cache only saves time, so the scan is good without it
+		_ = writeCache(opts, dir, t)
+	}
+	return t, nil
+}
+
+// scanDir adds the entries of the folder id, found at dir, to t. pid is the
+// same folder in the cached scan, or NoNode. A folder that can't be read is
+// marked as such, and the error is returned for the scan of the root to
+// report.
+func (s *scanner) scanDir(t *Tree, id NodeID, dir string, pid NodeID) error {
+	s.opts.Progress.enter(s.path(dir))
+	s.opts.Progress.add(t.Size(id))
+
+	if s.unchanged(t, id, pid) {
+		s.reuseDir(t, id, dir, pid)
+		t.total(id)
+		return nil
+	}
+	entries, err := fs.ReadDir(s.fsys, dir)
+	if err != nil {
+		t.nodes[id].flags |= nodeUnread
+		s.opts.Progress.fail()
+		return err
+	}
+	var cached map[string]NodeID
+	if pid != NoNode {
+		cached = map[string]NodeID{}
+		for _, c := range s.prev.Children(pid) {
+			cached[s.prev.Name(c)] = c
+		}
+	}
+	for _, e := range entries {
+		f, err := e.Info()
+		if err != nil {
+			// removed since the directory was read
+			continue
+		}
+		name := f.Name()
+		p := path.Join(dir, name)
+		if f.IsDir() {
+			c, ok := cached[name]
+			if !ok {
+				c = NoNode
+			}
+			s.addDir(t, id, p, f, c)
+			continue
+		}
+		s.addFile(t, id, p, f)
+	}
+
+	// Maybe not count directory as 4K?
+	t.total(id)
+	return nil
+}
+
+// addFile adds the file at p, described by f, to the folder id of t and
+// returns it, or NoNode if it is left out.
+func (s *scanner) addFile(t *Tree, id NodeID, p string, f fs.FileInfo) NodeID {
+	name := f.Name()
+	if f.Mode()&os.ModeSymlink != 0 && s.opts.FollowSymlinks {
+		if target, err := fs.Stat(s.fsys, p); err == nil && !target.IsDir() {
+			f = target
+		}
+	}
+	if !s.cutoff.IsZero() && f.ModTime().After(s.cutoff) {
+		return NoNode
+	}
+	c := s.add(t, id, name, false)
+	t.setInfo(c, f)
+	n := &t.nodes[c]
+	if s.opts.Extended {
+		if at, ok := accessTime(f); ok {
+			n.accessTime = unixNanos(at)
+		}
+	}
+	if s.excluded(s.path(p), name) {
+		n.flags |= nodeExcluded
+		return c
+	}
+	s.opts.Progress.add(n.size)
+	if s.opts.Archives && s.archive == "" && f.Mode().IsRegular() {
+		if kind := archiveKind(name); kind != "" {
+			s.scanArchive(t, c, p, kind, f.Size())
+		}
+	}
+	return c
+}
+
+// addDir adds the folder at p, described by f, to the folder id of t and
+// scans it unless it is excluded or skipped, and returns it. pid is the same
+// folder in the cached scan, or NoNode.
+func (s *scanner) addDir(t *Tree, id NodeID, p string, f fs.FileInfo, pid NodeID) NodeID {
+	name := f.Name()
+	c := s.add(t, id, name, true)
+	n := &t.nodes[c]
+	n.mode = f.Mode()
+	n.modTime = unixNanos(f.ModTime())
+	n.uid, n.gid, _ = owner(f)
+	if s.excluded(s.path(p), name) {
+		n.flags |= nodeExcluded
+		return c
+	}
+	if s.skipDir(p, f) {
+		n.flags |= nodeUnread
+		return c
+	}
+	t.setInfo(c, f)
+	s.scanDir(t, c, p, pid)
+	return c
+}
+
+// unchanged reports whether the folder id of t has the same modification
+// and change times as pid in the cached scan, in which case its list of
+// entries is the same.
+func (s *scanner) unchanged(t *Tree, id, pid NodeID) bool {
+	if pid == NoNode {
+		return false
+	}
+	n, old := t.nodes[id], s.prev.nodes[pid]
+	return old.flags&(nodeDir|nodeArchive|nodeUnread) == nodeDir &&
+		n.modTime != 0 && n.modTime == old.modTime && n.changeTime == old.changeTime
+}
+
+// reuseDir adds the entries of the unchanged folder pid of the cached scan
+// to the folder id of t. The list of entries is the same, but a file may have
+// been rewritten in place without its folder changing, so every entry is
+// looked at again. Files are only copied if they still match the cache, and
+// folders are scanned since their own content may have changed.
+func (s *scanner) reuseDir(t *Tree, id NodeID, dir string, pid NodeID) {
+	for _, c := range s.prev.Children(pid) {
+		name := s.prev.Name(c)
+		p := path.Join(dir, name)
+		// the cache is only used on the local disk
+		f, err := os.Lstat(p)
+		if err != nil {
+			// removed since the folder was looked at
+			continue
+		}
+		if f.IsDir() {
+			if !s.prev.IsDir(c) || s.prev.IsArchive(c) {
+				c = NoNode
+			}
+			s.addDir(t, id, p, f, c)
+			continue
+		}
+		if !s.prev.nodes[c].describes(f) {
+			s.addFile(t, id, p, f)
+			continue
+		}
+		g := t.graft(id, name, s.prev, c)
+		t.link(id, g)
+		n := &t.nodes[g]
+		if s.opts.Extended {
+			// reading a file changes neither its modification nor its
+			// change time
+			if at, ok := accessTime(f); ok {
+				n.accessTime = unixNanos(at)
+			}
+		}
+		if n.flags&nodeExcluded == 0 {
+			s.opts.Progress.add(n.size)
+		}
+	}
+}
+
+// Update brings the entry at path up to date after it was created, changed
+// or removed on disk, e.g. when told so by a Watcher, and adds the difference
+// to the folders above it. A file that is still there is updated in place.
+// Folders are only reported when they are created, removed or moved, so an
+// existing one is replaced by a new scan with opts, without using the cache,
+// like new entries. It returns the entry, or NoNode if it is gone or its
+// folder isn't part of t. Only trees scanned from the local disk can be
+// updated.
+func (t *Tree) Update(path string, opts ScanOptions) (NodeID, error) {
+	if !t.Local() {
+		return NoNode, fmt.Errorf("error updating %s: not scanned from the local disk", path)
+	}
+	path = filepath.Clean(path)
+	parent, ok := t.Lookup(filepath.Dir(path))
+	if !ok || t.nodes[parent].flags&(nodeDir|nodeExcluded|nodeArchive|nodeInArchive|nodeUnread) != nodeDir {
+		return NoNode, nil
+	}
+	info, err := os.Lstat(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return NoNode, err
+	}
+	old, exists := t.Child(parent, filepath.Base(path))
+	if err != nil {
+		if exists {
+			t.Remove(old)
+		}
+		return NoNode, nil
+	}
+
+	s := &scanner{opts: opts, fsys: osFS{}, local: true}
+	s.opts.Progress = nil
+	if root, err := os.Stat(t.Name(t.Root())); err == nil {
+		s.device, _ = deviceID(root)
+	}
+	if opts.OlderThan > 0 {
+		s.cutoff = time.Now().Add(-opts.OlderThan)
+	}
+	if exists {
+		if s.updateFile(t, old, path, info) {
+			return old, nil
+		}
+		t.Remove(old)
+	}
+	var id NodeID
+	if info.IsDir() {
+		id = s.addDir(t, parent, path, info, NoNode)
+	} else {
+		id = s.addFile(t, parent, path, info)
+	}
+	if id != NoNode && !t.Excluded(id) {
+		n := t.nodes[id]
+		t.propagate(parent, n.size, n.apparent, n.items+1)
+	}
+	return id, nil
+}
+
+// updateFile brings the file id of t up to date with f, found at p, in
+// place, and adds the difference in size to the folders above it. It
+// reports false if the entry has to be added again instead, because it is
+// or was a folder or an archive, changed type, or is now left out by
+// OlderThan.
+func (s *scanner) updateFile(t *Tree, id NodeID, p string, f fs.FileInfo) bool {
+	old := t.nodes[id]
+	if f.IsDir() || old.flags&(nodeDir|nodeArchive) != 0 {
+		return false
+	}
+	if f.Mode()&os.ModeSymlink != 0 && s.opts.FollowSymlinks {
+		if target, err := fs.Stat(s.fsys, p); err == nil && !target.IsDir() {
+			f = target
+		}
+	}
+	switch {
+	case f.Mode().Type() != old.mode.Type():
+		return false
+	case !s.cutoff.IsZero() && f.ModTime().After(s.cutoff):
+		return false
+	case s.opts.Archives && f.Mode().IsRegular() && archiveKind(t.Name(id)) != "":
+		// list its new content
+		return false
+	}
+
+	t.setInfo(id, f)
+	n := &t.nodes[id]
+	if s.opts.Extended {
+		if at, ok := accessTime(f); ok {
+			n.accessTime = unixNanos(at)
+		}
+	}
+	changed := n.apparent != old.apparent || n.modTime != old.modTime
+	if changed {
+		n.hash = 0
+	}
+	if n.flags&nodeExcluded == 0 && (changed || n.size != old.size) {
+		t.propagate(n.parent, n.size-old.size, n.apparent-old.apparent, 0)
+	}
+	return true
+}
+
+// scanArchive lists the content of the archive id, found at name, below it.
+// Archives that can't be read are kept as plain files.
+func (s *scanner) scanArchive(t *Tree, id NodeID, name, kind string, size int64) {
+	afs, err := openArchive(s.fsys, name, kind, size)
+	if err != nil {
+		return
+	}
+	sub := &scanner{opts: s.opts, fsys: afs, cutoff: s.cutoff, archive: s.path(name)}
+	sub.opts.OneFileSystem = false
+	sub.opts.Progress = nil
+
+	// the archive keeps its own size, whatever its content adds up to
+	n := &t.nodes[id]
+	n.flags |= nodeDir | nodeArchive
+	size, apparent := n.size, n.apparent
+	sub.scanDir(t, id, ".", NoNode)
+	n = &t.nodes[id]
+	n.size, n.apparent = size, apparent
+}
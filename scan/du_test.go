@@ -0,0 +1,236 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// pngHeader is enough of a PNG file for its type to be recognized.
+const pngHeader = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+func testFS() fstest.MapFS {
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return fstest.MapFS{
+		"src/du.go":     {Data: []byte(pngHeader), ModTime: when},
+		"src/copy.go":   {Data: []byte(pngHeader), ModTime: when},
+		"src/notes.txt": {Data: []byte("some notes"), ModTime: when},
+		"src/big.bin": {
+			Data: make([]byte, 10000),
+			Sys:  &Stat{DiskUsage: 12288, UID: 1000, GID: 100},
+		},
+		"build/out.o": {Data: []byte("object")},
+		"empty":       {Data: nil},
+	}
+}
+
+func TestScanTreeFS(t *testing.T) {
+	tree, err := ScanTree(".", ScanOptions{FS: testFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.Root()
+	if got := tree.Items(root); got != 8 {
+		t.Errorf("Items = %d, want 8", got)
+	}
+	apparent := int64(2*len(pngHeader) + len("some notes") + 10000 + len("object"))
+	if got := tree.ApparentSize(root); got != apparent {
+		t.Errorf("ApparentSize = %d, want %d", got, apparent)
+	}
+
+	big, ok := tree.Lookup("src/big.bin")
+	if !ok {
+		t.Fatal("src/big.bin not found")
+	}
+	if got := tree.Size(big); got != 12288 {
+		t.Errorf("Size of big.bin = %d, want the disk usage from Stat", got)
+	}
+	if got := tree.UID(big); got != 1000 {
+		t.Errorf("UID of big.bin = %d, want 1000", got)
+	}
+	notes, _ := tree.Lookup("src/notes.txt")
+	if got := tree.Size(notes); got != int64(len("some notes")) {
+		t.Errorf("Size of notes.txt = %d, want its length without Stat", got)
+	}
+}
+
+func TestScanTreeFSExclude(t *testing.T) {
+	tree, err := ScanTree(".", ScanOptions{FS: testFS(), Exclude: []string{"build", "*.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"build", "src/notes.txt"} {
+		id, ok := tree.Lookup(name)
+		if !ok {
+			t.Fatalf("%s not found", name)
+		}
+		if !tree.Excluded(id) {
+			t.Errorf("%s isn't excluded", name)
+		}
+	}
+	apparent := int64(2*len(pngHeader) + 10000)
+	if got := tree.ApparentSize(tree.Root()); got != apparent {
+		t.Errorf("ApparentSize = %d, want %d without the excluded entries", got, apparent)
+	}
+}
+
+func TestScanTreeFSNotFound(t *testing.T) {
+	if _, err := ScanTree("missing", ScanOptions{FS: testFS()}); err == nil {
+		t.Error("scanning a missing folder succeeded")
+	}
+	if _, err := ScanTree("src/du.go", ScanOptions{FS: testFS()}); err == nil {
+		t.Error("scanning a file succeeded")
+	}
+}
+
+// The content of the files is read from the scanned FS, not from the paths
+// of the same name on disk, such as the du.go next to this test.
+func TestByContentTypeFS(t *testing.T) {
+	tree, err := ScanTree("src", ScanOptions{FS: testFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"image": 2, "text": 1, "application": 1}
+	groups := tree.ByContentType(tree.Root())
+	if len(groups) != len(want) {
+		t.Fatalf("found %d types, want %d: %+v", len(groups), len(want), groups)
+	}
+	for _, g := range groups {
+		if len(g.Files) != want[g.Name] {
+			t.Errorf("%d files of type %q, want %d", len(g.Files), g.Name, want[g.Name])
+		}
+	}
+}
+
+func TestFindDuplicatesFS(t *testing.T) {
+	tree, err := ScanTree(".", ScanOptions{FS: testFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := tree.FindDuplicates(tree.Root(), 2)
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Fatalf("groups = %+v, want du.go and copy.go", groups)
+	}
+	for i, want := range []string{"src/copy.go", "src/du.go"} {
+		if got := groups[0].Files[i].FullPath(); got != want {
+			t.Errorf("file %d = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestCreateFileTreeFS(t *testing.T) {
+	root, err := CreateFileTree(".", ScanOptions{FS: testFS(), ComputeHashes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Items != 8 {
+		t.Errorf("Items = %d, want 8", root.Items)
+	}
+	hashes := map[string]uint64{}
+	for _, folder := range root.Folders {
+		for _, f := range folder.Files {
+			hashes[f.Name] = f.Hash
+		}
+	}
+	if hashes["du.go"] == 0 || hashes["du.go"] != hashes["copy.go"] {
+		t.Errorf("hashes of du.go and copy.go = %x and %x, want the same one", hashes["du.go"], hashes["copy.go"])
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "one", "sub/b": "two"})
+	tree, err := ScanTree(dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := func() *Tree {
+		t.Helper()
+		want, err := ScanTree(dir, ScanOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return want
+	}
+
+	// a file changed in place keeps its entry
+	b, _ := tree.Lookup(filepath.Join(dir, "sub", "b"))
+	nodes := tree.Len()
+	for i := 0; i < 3; i++ {
+		writeFiles(t, dir, map[string]string{"sub/b": strings.Repeat("x", 100*(i+1))})
+		id, err := tree.Update(filepath.Join(dir, "sub", "b"), ScanOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != b {
+			t.Fatalf("Update returned %d, want the same entry %d", id, b)
+		}
+	}
+	if tree.Len() != nodes {
+		t.Errorf("Len = %d after changing a file, want %d", tree.Len(), nodes)
+	}
+	want := fresh()
+	if got, want := tree.ApparentSize(tree.Root()), want.ApparentSize(want.Root()); got != want {
+		t.Errorf("ApparentSize = %d, want %d", got, want)
+	}
+
+	// new and removed entries
+	writeFiles(t, dir, map[string]string{"c": "three"})
+	if err := os.Remove(filepath.Join(dir, "a")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "c"} {
+		if _, err := tree.Update(filepath.Join(dir, name), ScanOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := tree.Lookup(filepath.Join(dir, "a")); ok {
+		t.Error("removed file is still listed")
+	}
+	if _, ok := tree.Lookup(filepath.Join(dir, "c")); !ok {
+		t.Error("new file isn't listed")
+	}
+	want = fresh()
+	if tree.ApparentSize(tree.Root()) != want.ApparentSize(want.Root()) || tree.Items(tree.Root()) != want.Items(want.Root()) {
+		t.Errorf("totals = %d, %d items, want %d, %d items", tree.ApparentSize(tree.Root()),
+			tree.Items(tree.Root()), want.ApparentSize(want.Root()), want.Items(want.Root()))
+	}
+}
+
+// Trees scanned from an FS never touch the files of the same name on disk.
+func TestDeleteFS(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/notes.txt": "on disk"})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tree, err := ScanTree(".", ScanOptions{FS: testFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Local() {
+		t.Error("a tree scanned from an FS is local")
+	}
+	notes, _ := tree.Lookup("src/notes.txt")
+	if err := tree.Delete(notes); err == nil {
+		t.Error("deleting from an FS succeeded")
+	}
+	if _, err := tree.Update("src/notes.txt", ScanOptions{}); err == nil {
+		t.Error("updating from the disk succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "notes.txt")); err != nil {
+		t.Errorf("the file on disk is gone: %v", err)
+	}
+	if _, ok := tree.Lookup("src/notes.txt"); !ok {
+		t.Error("the entry was removed from the tree")
+	}
+}
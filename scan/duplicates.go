@@ -0,0 +1,421 @@
+package scan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// partialHashSize is how much of a file is read to weed out files that only
+// happen to have the same size before reading them in full.
+const partialHashSize = 4096
+
+// DuplicateGroup is a set of files with identical content. Hard links to the
+// same file are only listed once since they don't waste any space.
+type DuplicateGroup struct {
+	Size  int64
+	Hash  uint64
+	Files []File
+}
+
+// Wasted returns the space that would be freed by keeping only one copy.
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// FullPath returns the path of the file including its directory.
+func (f File) FullPath() string {
+	return filepath.Join(f.HighDir, f.Name)
+}
+
+// WalkFiles calls fn with a pointer to every file below folder, so that fn
+// can update the tree in place.
+func WalkFiles(folder *Folder, fn func(*File)) {
+	for i := range folder.Files {
+		fn(&folder.Files[i])
+	}
+	for i := range folder.Folders {
+		WalkFiles(&folder.Folders[i], fn)
+	}
+}
+
+// FindDuplicates returns the groups of files below root with identical
+// content, the ones wasting the most space first, see Tree.FindDuplicates.
+// The hash of the files that could have a copy is stored in the Hash field
+// of the files in root, and files that already have one aren't read again.
+func FindDuplicates(root *Folder, workers int) []DuplicateGroup {
+	t := FromFolder(*root)
+	groups := t.FindDuplicates(t.Root(), workers)
+	t.copyHashes(t.Root(), root)
+	return groups
+}
+
+// copyHashes copies the hashes of the folder id and everything below it to
+// folder, which t was made from with FromFolder.
+func (t *Tree) copyHashes(id NodeID, folder *Folder) {
+	folder.Hash = t.nodes[id].hash
+	c := t.nodes[id].firstChild
+	// FromFolder adds the folders first, then the files
+	for i := range folder.Folders {
+		t.copyHashes(c, &folder.Folders[i])
+		c = t.nodes[c].nextSibling
+	}
+	for i := range folder.Files {
+		folder.Files[i].Hash = t.nodes[c].hash
+		c = t.nodes[c].nextSibling
+	}
+}
+
+// FindDuplicates returns the groups of files below the folder id with
+// identical content, the ones wasting the most space first. Files are first
+// grouped by size, then by a hash of their first few KiB and only then
+// hashed in full, using workers goroutines. The full hash is stored in t,
+// see Hash, and files that already have one aren't read again.
+//
+// To find duplicates while t is in use, read the files of DuplicateCandidates
+// with FileList.FindDuplicates instead, and store what was found with
+// SetHashes.
+func (t *Tree) FindDuplicates(id NodeID, workers int) []DuplicateGroup {
+	l := t.DuplicateCandidates(id)
+	groups := l.FindDuplicates(workers)
+	t.SetHashes(l)
+	return groups
+}
+
+// DuplicateCandidates returns the files below the folder id that have the
+// same size as another one, which are the only ones that can have a copy.
+// Empty files, files in archives and excluded files are left out.
+func (t *Tree) DuplicateCandidates(id NodeID) *FileList {
+	bySize := map[int64][]NodeID{}
+	t.walkBelow(id, func(c NodeID) bool {
+		n := &t.nodes[c]
+		switch {
+		case n.flags&nodeExcluded != 0:
+			return false
+		case n.flags&(nodeDir|nodeInArchive) != 0 || !n.mode.IsRegular() || n.apparent == 0:
+			return true
+		}
+		bySize[n.apparent] = append(bySize[n.apparent], c)
+		return true
+	})
+
+	l := &FileList{fsys: t.fsys}
+	for _, ids := range bySize {
+		if len(ids) < 2 {
+			continue
+		}
+		for _, c := range ids {
+			l.files = append(l.files, t.listedFile(c))
+		}
+	}
+	return l
+}
+
+// SetHashes stores the hashes l.FindDuplicates computed in t, except for the
+// files that changed in t since l was made.
+func (t *Tree) SetHashes(l *FileList) {
+	for _, f := range l.files {
+		if f.hash == 0 || int(f.id) >= len(t.nodes) {
+			continue
+		}
+		n := &t.nodes[f.id]
+		if n.apparent == f.entry.ApparentSize && unixTime(n.modTime).Equal(f.entry.ModTime) {
+			n.hash = f.hash
+		}
+	}
+}
+
+// FindDuplicates reads the files of l, which come from DuplicateCandidates,
+// and returns the groups with identical content, see Tree.FindDuplicates.
+// It doesn't use the tree l was taken from.
+func (l *FileList) FindDuplicates(workers int) []DuplicateGroup {
+	type key struct {
+		size int64
+		hash uint64
+	}
+	bySize := map[int64][]*listedFile{}
+	for i := range l.files {
+		f := &l.files[i]
+		bySize[f.entry.ApparentSize] = append(bySize[f.entry.ApparentSize], f)
+	}
+	candidates := []*listedFile{}
+	for _, files := range bySize {
+		if len(files) > 1 {
+			candidates = append(candidates, files...)
+		}
+	}
+
+	// narrow down by the start of the files
+	partial := hashFiles(candidates, workers, func(f *listedFile) (uint64, error) {
+		if f.entry.ApparentSize <= partialHashSize {
+			return 0, nil
+		}
+		return l.hash(f, partialHashSize)
+	})
+	byPartial := map[key][]*listedFile{}
+	for i, f := range candidates {
+		if h, ok := partial[i]; ok {
+			k := key{f.entry.ApparentSize, h}
+			byPartial[k] = append(byPartial[k], f)
+		}
+	}
+
+	candidates = candidates[:0]
+	for _, files := range byPartial {
+		if len(files) > 1 {
+			candidates = append(candidates, files...)
+		}
+	}
+
+	full := hashFiles(candidates, workers, func(f *listedFile) (uint64, error) {
+		if f.hash != 0 {
+			return f.hash, nil
+		}
+		return l.hash(f, -1)
+	})
+	byHash := map[key][]*listedFile{}
+	for i, f := range candidates {
+		if h, ok := full[i]; ok {
+			f.hash = h
+			k := key{f.entry.ApparentSize, h}
+			byHash[k] = append(byHash[k], f)
+		}
+	}
+
+	groups := []DuplicateGroup{}
+	for k, files := range byHash {
+		files = l.distinct(files)
+		if len(files) < 2 {
+			continue
+		}
+		group := DuplicateGroup{Size: k.size, Hash: k.hash}
+		for _, f := range files {
+			group.Files = append(group.Files, f.file())
+		}
+		sort.Slice(group.Files, func(i, j int) bool {
+			return group.Files[i].FullPath() < group.Files[j].FullPath()
+		})
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].Files[0].FullPath() < groups[j].Files[0].FullPath()
+	})
+	return groups
+}
+
+// hashFiles runs hash on every file using workers goroutines. Files that
+// couldn't be read are missing from the result.
+func hashFiles(files []*listedFile, workers int, hash func(*listedFile) (uint64, error)) map[int]uint64 {
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[int]uint64, len(files))
+		jobs    = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				h, err := hash(files[i])
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				results[i] = h
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// hash hashes the first n bytes of the file f, or all of it if n is
+// negative. The hash is the start of a SHA-256 digest, so that files with
+// different content can't easily be made to look alike, but it is still too
+// short to be sure that two files are the same, see SameContent.
+func (l *FileList) hash(f *listedFile, n int64) (uint64, error) {
+	file, err := l.open(f)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if n >= 0 {
+		r = io.LimitReader(file, n)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(h.Sum(nil)), nil
+}
+
+// distinct drops hard links to a file that is already in files.
+func (l *FileList) distinct(files []*listedFile) []*listedFile {
+	distinct := []*listedFile{}
+	infos := []fs.FileInfo{}
+next:
+	for _, f := range files {
+		info, err := l.stat(f)
+		if err != nil {
+			continue
+		}
+		for _, seen := range infos {
+			if sameFile(info, seen) {
+				continue next
+			}
+		}
+		distinct = append(distinct, f)
+		infos = append(infos, info)
+	}
+	return distinct
+}
+
+// sameFile reports whether a and b describe the same file, such as two hard
+// links to it.
+func sameFile(a, b fs.FileInfo) bool {
+	sa, okA := statOf(a)
+	sb, okB := statOf(b)
+	if okA && okB && sa.Inode != 0 {
+		return sa.Device == sb.Device && sa.Inode == sb.Inode
+	}
+	return os.SameFile(a, b)
+}
+
+// SameContent reports whether the files or folders at a and b have exactly
+// the same content, comparing them byte by byte rather than trusting their
+// hashes. Folders have to hold the same names, each with the same content.
+// It is meant to be checked right before deleting or linking a copy.
+func SameContent(a, b string) (bool, error) {
+	ia, err := os.Lstat(a)
+	if err != nil {
+		return false, err
+	}
+	ib, err := os.Lstat(b)
+	if err != nil {
+		return false, err
+	}
+	if os.SameFile(ia, ib) {
+		return true, nil
+	}
+	if ia.Mode().Type() != ib.Mode().Type() {
+		return false, nil
+	}
+	switch {
+	case ia.IsDir():
+		return sameFolders(a, b)
+	case ia.Mode()&os.ModeSymlink != 0:
+		ta, err := os.Readlink(a)
+		if err != nil {
+			return false, err
+		}
+		tb, err := os.Readlink(b)
+		return ta == tb, err
+	case ia.Mode().IsRegular():
+		if ia.Size() != ib.Size() {
+			return false, nil
+		}
+		return sameFiles(a, b)
+	}
+	// devices, pipes and sockets have no content to compare
+	return false, nil
+}
+
+func sameFolders(a, b string) (bool, error) {
+	ea, err := os.ReadDir(a)
+	if err != nil {
+		return false, err
+	}
+	eb, err := os.ReadDir(b)
+	if err != nil {
+		return false, err
+	}
+	if len(ea) != len(eb) {
+		return false, nil
+	}
+	// both are sorted by name
+	for i := range ea {
+		if ea[i].Name() != eb[i].Name() {
+			return false, nil
+		}
+		same, err := SameContent(filepath.Join(a, ea[i].Name()), filepath.Join(b, eb[i].Name()))
+		if !same || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func sameFiles(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA, bufB := make([]byte, 64*1024), make([]byte, 64*1024)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		endA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		endB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		switch {
+		case errA != nil && !endA:
+			return false, errA
+		case errB != nil && !endB:
+			return false, errB
+		case endA || endB:
+			return endA && endB, nil
+		}
+	}
+}
+
+// LinkDuplicate replaces the file at duplicate with a hard link to original,
+// after checking that both still have the same content, see SameContent.
+// Both have to be on the same filesystem.
+func LinkDuplicate(original, duplicate string) error {
+	same, err := SameContent(original, duplicate)
+	if err != nil {
+		return fmt.Errorf("error comparing %s to %s: %w", duplicate, original, err)
+	}
+	if !same {
+		return fmt.Errorf("error linking %s: its content differs from %s", duplicate, original)
+	}
+	tmp := duplicate + ".godu-link"
+	if err := os.Link(original, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, duplicate); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
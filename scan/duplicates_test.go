@@ -0,0 +1,191 @@
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	big := strings.Repeat("x", 2*partialHashSize)
+	writeFiles(t, dir, map[string]string{
+		"a.txt":     "same content",
+		"sub/b.txt": "same content",
+		"c.txt":     "diff content",
+		"big1":      big,
+		"sub/big2":  big,
+		"big3":      big[:len(big)-1] + "y",
+		"empty1":    "",
+		"empty2":    "",
+	})
+
+	tree, err := ScanTree(dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := tree.FindDuplicates(tree.Root(), 2)
+	if len(groups) != 2 {
+		t.Fatalf("found %d groups, want 2: %+v", len(groups), groups)
+	}
+	want := [][]string{{"big1", "sub/big2"}, {"a.txt", "sub/b.txt"}}
+	for i, g := range groups {
+		if len(g.Files) != 2 {
+			t.Fatalf("group %d has %d files, want 2", i, len(g.Files))
+		}
+		for j, f := range g.Files {
+			if path := filepath.Join(dir, want[i][j]); f.FullPath() != path {
+				t.Errorf("group %d file %d = %s, want %s", i, j, f.FullPath(), path)
+			}
+		}
+	}
+
+	// the hashes are kept in the tree
+	a, _ := tree.Lookup(filepath.Join(dir, "a.txt"))
+	b, _ := tree.Lookup(filepath.Join(dir, "sub", "b.txt"))
+	c, _ := tree.Lookup(filepath.Join(dir, "c.txt"))
+	if tree.Hash(a) == 0 || tree.Hash(a) != tree.Hash(b) {
+		t.Errorf("hashes of copies = %x and %x, want the same one", tree.Hash(a), tree.Hash(b))
+	}
+	if tree.Hash(c) == 0 || tree.Hash(c) == tree.Hash(a) {
+		t.Errorf("hash of c.txt = %x, want a different one than %x", tree.Hash(c), tree.Hash(a))
+	}
+}
+
+func TestSetHashesSkipsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "one", "b": "one"})
+	tree, err := ScanTree(dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := tree.DuplicateCandidates(tree.Root())
+	files.FindDuplicates(1)
+
+	// a changed while the files were being read
+	writeFiles(t, dir, map[string]string{"a": "two!"})
+	a, err := tree.Update(filepath.Join(dir, "a"), ScanOptions{})
+	if err != nil || a == NoNode {
+		t.Fatalf("Update(a) = %d, %v", a, err)
+	}
+	tree.SetHashes(files)
+	b, _ := tree.Lookup(filepath.Join(dir, "b"))
+	if tree.Hash(a) != 0 {
+		t.Errorf("hash of changed file = %x, want 0", tree.Hash(a))
+	}
+	if tree.Hash(b) == 0 {
+		t.Error("hash of unchanged file wasn't stored")
+	}
+}
+
+func TestSameContent(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/x":   "content",
+		"a/d/y": "more",
+		"b/x":   "content",
+		"b/d/y": "more",
+		"c/x":   "content",
+		"c/d/y": "mode",
+		"d/x":   "content",
+	})
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a/x", "b/x", true},
+		{"a/d/y", "c/d/y", false},
+		{"a", "b", true},
+		{"a", "c", false},
+		{"a", "d", false},
+		{"a/x", "a", false},
+	}
+	for _, tt := range tests {
+		got, err := SameContent(filepath.Join(dir, tt.a), filepath.Join(dir, tt.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("SameContent(%s, %s) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLinkDuplicateChecksContent(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "one", "b": "two", "c": "one"})
+	a, b, c := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")
+
+	if err := LinkDuplicate(a, b); err == nil {
+		t.Error("linked files with different content")
+	}
+	if data, _ := os.ReadFile(b); string(data) != "two" {
+		t.Errorf("b = %q after a refused link, want %q", data, "two")
+	}
+
+	if err := LinkDuplicate(a, c); err != nil {
+		t.Fatal(err)
+	}
+	ia, _ := os.Stat(a)
+	ic, _ := os.Stat(c)
+	if !os.SameFile(ia, ic) {
+		t.Error("c isn't a hard link to a")
+	}
+}
+
+func TestIdenticalFolders(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/x":       "one",
+		"a/sub/y":   "two",
+		"b/x":       "one",
+		"b/sub/y":   "two",
+		"renamed/z": "one",
+		"renamed/y": "two",
+	})
+	tree, err := ScanTree(dir, ScanOptions{ComputeHashes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := tree.Lookup(filepath.Join(dir, "a"))
+	if tree.Hash(a) == 0 {
+		t.Error("ScanTree with ComputeHashes left the folder hash 0")
+	}
+	groups := tree.IdenticalFolders(tree.Root())
+	if len(groups) != 1 || len(groups[0].Folders) != 2 {
+		t.Fatalf("groups = %+v, want a and b", groups)
+	}
+	for i, name := range []string{"a", "b"} {
+		if got, want := groups[0].Folders[i].Path, filepath.Join(dir, name); got != want {
+			t.Errorf("folder %d = %s, want %s", i, got, want)
+		}
+	}
+
+	// changing a file clears the hashes of the folders above it
+	y, _ := tree.Lookup(filepath.Join(dir, "a", "sub", "y"))
+	tree.Remove(y)
+	if tree.Hash(a) != 0 {
+		t.Errorf("hash of a = %x after removing a file in it, want 0", tree.Hash(a))
+	}
+}
+
+// The size of a folder itself, which depends on how many entries it ever
+// had, doesn't keep it from matching a copy.
+func TestIdenticalFoldersIgnoreFolderSize(t *testing.T) {
+	file := File{Name: "x", Size: 4096, ApparentSize: 3, Mode: 0644}
+	root := Folder{Path: "/r", Name: "r", Mode: fs.ModeDir | 0755, Folders: []Folder{
+		{Path: "/r/a", Name: "a", Mode: fs.ModeDir | 0755, Size: 4096, ApparentSize: 4096, Items: 1, Files: []File{file}},
+		{Path: "/r/b", Name: "b", Mode: fs.ModeDir | 0755, Size: 65536, ApparentSize: 65536, Items: 1, Files: []File{file}},
+	}}
+	tree := FromFolder(root)
+	for _, id := range tree.Children(tree.Root()) {
+		x, _ := tree.Child(id, "x")
+		tree.nodes[x].hash = 1
+	}
+	groups := tree.IdenticalFolders(tree.Root())
+	if len(groups) != 1 || len(groups[0].Folders) != 2 {
+		t.Fatalf("groups = %+v, want a and b", groups)
+	}
+}
@@ -0,0 +1,49 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// exportVersion is bumped whenever the layout of an export changes in a way
+// older versions of godu can't read.
+const exportVersion = 1
+
+// export is the file format written by Export. The tree is stored as is, so
+// an import gives back exactly what was scanned.
+type export struct {
+	Version   int       `json:"version"`
+	Program   string    `json:"program"`
+	Timestamp time.Time `json:"timestamp"`
+	Root      Folder    `json:"root"`
+}
+
+// Export writes root to w as JSON. program is recorded in the header to tell
+// which version of godu wrote the file.
+func Export(w io.Writer, root Folder, program string) error {
+	enc := json.NewEncoder(w)
+	err := enc.Encode(export{
+		Version:   exportVersion,
+		Program:   program,
+		Timestamp: time.Now(),
+		Root:      root,
+	})
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %w", root.Path, err)
+	}
+	return nil
+}
+
+// Import reads a tree previously written by Export.
+func Import(r io.Reader) (Folder, error) {
+	var e export
+	if err := json.NewDecoder(r).Decode(&e); err != nil {
+		return Folder{}, fmt.Errorf("error importing: %w", err)
+	}
+	if e.Version != exportVersion {
+		return Folder{}, fmt.Errorf("error importing: unsupported export version %d", e.Version)
+	}
+	return e.Root, nil
+}
@@ -0,0 +1,86 @@
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileList is the files below a folder of a Tree, taken out of it so that
+// their content can be read, e.g. in the background, while the tree keeps
+// changing. See Tree.Files.
+type FileList struct {
+	// fsys is the filesystem of the tree, or nil for the local disk.
+	fsys  fs.FS
+	files []listedFile
+}
+
+// listedFile is a file of a FileList.
+type listedFile struct {
+	id        NodeID
+	entry     Entry
+	mode      fs.FileMode
+	inArchive bool
+	// hash is the content hash of the file, or 0 if it isn't known yet.
+	hash uint64
+}
+
+// Files returns the files below the folder id that aren't excluded.
+func (t *Tree) Files(id NodeID) *FileList {
+	l := &FileList{fsys: t.fsys}
+	t.walkBelow(id, func(c NodeID) bool {
+		if t.Excluded(c) {
+			return false
+		}
+		if !t.IsDir(c) {
+			l.files = append(l.files, t.listedFile(c))
+		}
+		return true
+	})
+	return l
+}
+
+func (t *Tree) listedFile(id NodeID) listedFile {
+	return listedFile{
+		id:        id,
+		entry:     t.Entry(id),
+		mode:      t.Mode(id),
+		inArchive: t.InArchive(id),
+		hash:      t.Hash(id),
+	}
+}
+
+// open opens the file f on the filesystem of the tree it was taken from.
+func (l *FileList) open(f *listedFile) (fs.File, error) {
+	if l.fsys == nil {
+		return os.Open(f.entry.Path)
+	}
+	return l.fsys.Open(filepath.ToSlash(f.entry.Path))
+}
+
+// stat returns the details of the file f, following symbolic links.
+func (l *FileList) stat(f *listedFile) (fs.FileInfo, error) {
+	if l.fsys == nil {
+		return os.Stat(f.entry.Path)
+	}
+	return fs.Stat(l.fsys, filepath.ToSlash(f.entry.Path))
+}
+
+// file returns f as a File.
+func (f listedFile) file() File {
+	e := f.entry
+	return File{
+		Path:         filepath.Base(e.Path),
+		HighDir:      filepath.Dir(e.Path),
+		Name:         filepath.Base(e.Path),
+		Size:         e.Size,
+		ApparentSize: e.ApparentSize,
+		HumanSize:    PrettyPrintSize(e.Size),
+		Mode:         f.mode,
+		ModTime:      e.ModTime,
+		UID:          e.UID,
+		GID:          e.GID,
+		Hash:         f.hash,
+		InArchive:    f.inArchive,
+	}
+}
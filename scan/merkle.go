@@ -0,0 +1,138 @@
+package scan
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+)
+
+// FolderGroup is a set of folders with identical content.
+type FolderGroup struct {
+	Size    int64
+	Hash    uint64
+	Folders []Entry
+}
+
+// Reclaimable returns the space that would be freed by keeping only one
+// copy of the folder.
+func (g FolderGroup) Reclaimable() int64 {
+	return g.Size * int64(len(g.Folders)-1)
+}
+
+// FindIdenticalFolders is Tree.FindIdenticalFolders for a Folder. The Hash
+// of every file and folder that could have a copy is stored in root.
+func FindIdenticalFolders(root *Folder, workers int) []FolderGroup {
+	t := FromFolder(*root)
+	groups := t.FindIdenticalFolders(t.Root(), workers)
+	t.copyHashes(t.Root(), root)
+	return groups
+}
+
+// FindIdenticalFolders hashes the files below the folder id that could have
+// a copy, see FindDuplicates, and returns the groups of folders with the
+// same content, see IdenticalFolders.
+func (t *Tree) FindIdenticalFolders(id NodeID, workers int) []FolderGroup {
+	t.FindDuplicates(id, workers)
+	return t.IdenticalFolders(id)
+}
+
+// IdenticalFolders returns the groups of folders below id with the same
+// content, the ones that would free the most space first, going by the file
+// hashes already in t. Only the topmost folders of identical subtrees are
+// reported, not every pair of identical children inside them. The hash of
+// every folder below id is updated on the way, see Hash.
+func (t *Tree) IdenticalFolders(id NodeID) []FolderGroup {
+	t.hashFolder(id)
+
+	byHash := map[uint64][]NodeID{}
+	t.walkBelow(id, func(c NodeID) bool {
+		if !t.IsDir(c) {
+			return false
+		}
+		if h := t.nodes[c].hash; h != 0 {
+			byHash[h] = append(byHash[h], c)
+		}
+		return true
+	})
+	duplicated := map[NodeID]bool{}
+	for _, folders := range byHash {
+		if len(folders) > 1 {
+			for _, c := range folders {
+				duplicated[c] = true
+			}
+		}
+	}
+
+	groups := []FolderGroup{}
+	for hash, folders := range byHash {
+		if len(folders) < 2 || t.trivialCopies(folders, duplicated) {
+			continue
+		}
+		group := FolderGroup{Size: t.Size(folders[0]), Hash: hash}
+		for _, c := range folders {
+			group.Folders = append(group.Folders, t.Entry(c))
+		}
+		sort.Slice(group.Folders, func(i, j int) bool { return group.Folders[i].Path < group.Folders[j].Path })
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Reclaimable() != groups[j].Reclaimable() {
+			return groups[i].Reclaimable() > groups[j].Reclaimable()
+		}
+		return groups[i].Folders[0].Path < groups[j].Folders[0].Path
+	})
+	return groups
+}
+
+// hashFolder sets the hash of the folder id and every folder below it to a
+// Merkle hash of the names, types and hashes of its children, and returns
+// it. Sizes are left out since the content hashes already cover them. A
+// folder containing a file without a content hash can't have a copy, see
+// FindDuplicates, so its hash is left 0. The same goes for empty folders,
+// which aren't worth reporting.
+func (t *Tree) hashFolder(id NodeID) uint64 {
+	children := t.Children(id)
+	sort.Slice(children, func(i, j int) bool { return t.Name(children[i]) < t.Name(children[j]) })
+
+	h := fnv.New64a()
+	unique := false
+	buf := make([]byte, 8)
+	for _, c := range children {
+		kind := byte('f')
+		if t.IsDir(c) {
+			// hash every subfolder, even if this one turns out to be unique
+			kind = 'd'
+			t.hashFolder(c)
+		}
+		n := &t.nodes[c]
+		if n.flags&nodeExcluded != 0 {
+			continue
+		}
+		if n.hash == 0 && (kind == 'd' || n.apparent != 0) {
+			unique = true
+		}
+		h.Write([]byte{kind})
+		h.Write([]byte(t.Name(c)))
+		h.Write([]byte{0})
+		binary.LittleEndian.PutUint64(buf, n.hash)
+		h.Write(buf)
+	}
+
+	n := &t.nodes[id]
+	n.hash = 0
+	if !unique && n.items > 0 {
+		n.hash = h.Sum64()
+	}
+	return n.hash
+}
+
+// trivialCopies reports whether the parents of all folders are themselves
+// copies of each other, in which case the folders don't need reporting.
+func (t *Tree) trivialCopies(folders []NodeID, duplicated map[NodeID]bool) bool {
+	for _, c := range folders {
+		if !duplicated[t.nodes[c].parent] {
+			return false
+		}
+	}
+	return true
+}
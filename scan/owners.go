@@ -0,0 +1,108 @@
+package scan
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The files user and group names are looked up in.
+const (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+)
+
+var (
+	namesOnce  sync.Once
+	userNames  map[uint32]string
+	groupNames map[uint32]string
+)
+
+// readIDNames reads the name and numeric ID fields of a passwd(5) or
+// group(5) file. A missing or unreadable file gives no names.
+func readIDNames(path string) map[uint32]string {
+	names := map[uint32]string{}
+	file, err := os.Open(path)
+	if err != nil {
+		return names
+	}
+	defer file.Close()
+
+	reader := bufio.NewScanner(file)
+	for reader.Scan() {
+		line := reader.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		// the first entry wins, like getpwuid does
+		if _, ok := names[uint32(id)]; !ok {
+			names[uint32(id)] = fields[0]
+		}
+	}
+	return names
+}
+
+func loadNames() {
+	namesOnce.Do(func() {
+		userNames = readIDNames(passwdFile)
+		groupNames = readIDNames(groupFile)
+	})
+}
+
+// UserName returns the name of the user with the given ID, or the ID itself
+// if it has no entry in /etc/passwd.
+func UserName(uid uint32) string {
+	loadNames()
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(uid), 10)
+}
+
+// GroupName returns the name of the group with the given ID, or the ID
+// itself if it has no entry in /etc/group.
+func GroupName(gid uint32) string {
+	loadNames()
+	if name, ok := groupNames[gid]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(gid), 10)
+}
+
+// ByUser groups the files below folder by the user owning them, largest
+// group first.
+func ByUser(folder Folder) []TypeGroup {
+	t := FromFolder(folder)
+	return t.ByUser(t.Root())
+}
+
+// ByUser groups the files below the folder id by the user owning them.
+func (t *Tree) ByUser(id NodeID) []TypeGroup {
+	return t.groupFiles(id, func(c NodeID) string {
+		return UserName(t.UID(c))
+	})
+}
+
+// ByGroup groups the files below folder by their group, largest group
+// first.
+func ByGroup(folder Folder) []TypeGroup {
+	t := FromFolder(folder)
+	return t.ByGroup(t.Root())
+}
+
+// ByGroup groups the files below the folder id by their group.
+func (t *Tree) ByGroup(id NodeID) []TypeGroup {
+	return t.groupFiles(id, func(c NodeID) string {
+		return GroupName(t.GID(c))
+	})
+}
@@ -0,0 +1,90 @@
+package scan
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// ownersFS has files of two users and two groups, with IDs that are unlikely
+// to have names on the machine running the tests.
+func ownersFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a/one":      {Data: make([]byte, 100), Sys: &Stat{DiskUsage: 4096, UID: 4242, GID: 4343}},
+		"a/two":      {Data: make([]byte, 200), Sys: &Stat{DiskUsage: 4096, UID: 4242, GID: 4344}},
+		"b/three":    {Data: make([]byte, 1000), Sys: &Stat{DiskUsage: 12288, UID: 4243, GID: 4343}},
+		"b/skip.log": {Data: make([]byte, 5000), Sys: &Stat{DiskUsage: 8192, UID: 4242, GID: 4344}},
+	}
+}
+
+func TestOwners(t *testing.T) {
+	tree, err := ScanTree(".", ScanOptions{FS: ownersFS(), Exclude: []string{"*.log"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		groups []TypeGroup
+		want   []TypeGroup
+	}{
+		{"ByUser", tree.ByUser(tree.Root()), []TypeGroup{
+			{Name: UserName(4243), Size: 12288, ApparentSize: 1000},
+			{Name: UserName(4242), Size: 8192, ApparentSize: 300},
+		}},
+		{"ByGroup", tree.ByGroup(tree.Root()), []TypeGroup{
+			{Name: GroupName(4343), Size: 16384, ApparentSize: 1100},
+			{Name: GroupName(4344), Size: 4096, ApparentSize: 200},
+		}},
+		{"ByUser of a Folder", ByUser(tree.Folder(tree.Root())), []TypeGroup{
+			{Name: UserName(4243), Size: 12288, ApparentSize: 1000},
+			{Name: UserName(4242), Size: 8192, ApparentSize: 300},
+		}},
+	}
+	for _, tt := range tests {
+		if len(tt.groups) != len(tt.want) {
+			t.Errorf("%s: %d groups, want %d: %+v", tt.name, len(tt.groups), len(tt.want), tt.groups)
+			continue
+		}
+		var files int
+		for i, g := range tt.groups {
+			w := tt.want[i]
+			if g.Name != w.Name || g.Size != w.Size || g.ApparentSize != w.ApparentSize {
+				t.Errorf("%s: group %d = %s, %d, %d, want %s, %d, %d",
+					tt.name, i, g.Name, g.Size, g.ApparentSize, w.Name, w.Size, w.ApparentSize)
+			}
+			var size int64
+			for _, f := range g.Files {
+				size += f.Size
+			}
+			if size != g.Size {
+				t.Errorf("%s: the files of %s add up to %d, not %d", tt.name, g.Name, size, g.Size)
+			}
+			files += len(g.Files)
+		}
+		// the excluded file isn't counted
+		if files != 3 {
+			t.Errorf("%s: %d files, want 3", tt.name, files)
+		}
+	}
+}
+
+func TestReadIDNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"passwd": `# comment
+root:x:0:0:root:/root:/bin/sh
+
+alice:x:1000:1000::/home/alice:/bin/sh
+alias:x:1000:1000::/home/alice:/bin/sh
+broken:x
+nobody:x:nan:65534::/:/bin/false
+`})
+	names := readIDNames(filepath.Join(dir, "passwd"))
+	want := map[uint32]string{0: "root", 1000: "alice"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if names := readIDNames(filepath.Join(dir, "missing")); len(names) != 0 {
+		t.Errorf("names of a missing file = %v", names)
+	}
+}
@@ -0,0 +1,58 @@
+package scan
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Progress is updated by CreateFileTree while a scan is running so that
+// another goroutine can report on it. It is safe for concurrent use and the
+// zero value is ready to use.
+type Progress struct {
+	items  int64
+	size   int64
+	errors int64
+
+	mu   sync.Mutex
+	path string
+}
+
+func (p *Progress) enter(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.path = path
+	p.mu.Unlock()
+}
+
+func (p *Progress) add(size int64) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.items, 1)
+	atomic.AddInt64(&p.size, size)
+}
+
+func (p *Progress) fail() {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.errors, 1)
+}
+
+// Items returns the number of entries scanned so far.
+func (p *Progress) Items() int64 { return atomic.LoadInt64(&p.items) }
+
+// Size returns the disk usage of the entries scanned so far.
+func (p *Progress) Size() int64 { return atomic.LoadInt64(&p.size) }
+
+// Errors returns the number of directories that could not be read.
+func (p *Progress) Errors() int64 { return atomic.LoadInt64(&p.errors) }
+
+// Path returns the directory that is currently being read.
+func (p *Progress) Path() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.path
+}
@@ -0,0 +1,98 @@
+package scan
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SearchMode selects how the pattern of a Query is matched.
+type SearchMode int
+
+const (
+	// Substring matches names containing the pattern. The match ignores
+	// case unless the pattern has upper case letters.
+	Substring SearchMode = iota
+	// Glob matches shell patterns, see filepath.Match.
+	Glob
+	// Regexp matches regular expressions, see regexp.
+	Regexp
+)
+
+func (s SearchMode) String() string {
+	switch s {
+	case Substring:
+		return "substring"
+	case Glob:
+		return "glob"
+	case Regexp:
+		return "regexp"
+	}
+	return "unknown"
+}
+
+// Query is a search for entries in the tree.
+type Query struct {
+	Pattern string
+	Mode    SearchMode
+	// FullPath matches the pattern against full paths instead of names.
+	FullPath bool
+}
+
+// matcher compiles q.
+func (q Query) matcher() (func(string) bool, error) {
+	switch q.Mode {
+	case Glob:
+		if _, err := filepath.Match(q.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", q.Pattern, err)
+		}
+		return func(s string) bool {
+			ok, _ := filepath.Match(q.Pattern, s)
+			return ok
+		}, nil
+	case Regexp:
+		re, err := regexp.Compile(q.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", q.Pattern, err)
+		}
+		return re.MatchString, nil
+	}
+	if strings.ToLower(q.Pattern) == q.Pattern {
+		return func(s string) bool {
+			return strings.Contains(strings.ToLower(s), q.Pattern)
+		}, nil
+	}
+	return func(s string) bool {
+		return strings.Contains(s, q.Pattern)
+	}, nil
+}
+
+// Search returns every file and folder below root matching q, in the order
+// of the tree. Excluded entries are searched too since they are listed in
+// the browser as well.
+func Search(root Folder, q Query) ([]Entry, error) {
+	t := FromFolder(root)
+	return t.Search(t.Root(), q)
+}
+
+// Search returns every entry below the folder id matching q, like Search
+// does for a Folder.
+func (t *Tree) Search(id NodeID, q Query) ([]Entry, error) {
+	match, err := q.matcher()
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	t.walkBelow(id, func(c NodeID) bool {
+		subject := t.Name(c)
+		if q.FullPath {
+			subject = t.Path(c)
+		}
+		if match(subject) {
+			entries = append(entries, t.Entry(c))
+		}
+		return true
+	})
+	return entries, nil
+}
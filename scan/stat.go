@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"io/fs"
+	"time"
+)
+
+// Stat is what the scanner needs to know about an entry on top of its
+// fs.FileInfo. A filesystem scanned through ScanOptions.FS can return a
+// *Stat from the Sys method of its fs.FileInfo values. Without one the disk
+// usage of an entry is taken to be its size and its device, inode, owner and
+// access time are unknown.
+type Stat struct {
+	// DiskUsage is the number of bytes allocated for the entry, e.g. its
+	// number of blocks times the block size.
+	DiskUsage int64
+	// Device and Inode identify the entry on its filesystem.
+	Device uint64
+	Inode  uint64
+	UID    uint32
+	GID    uint32
+	// AccessTime, if not zero, is when the entry was last accessed.
+	AccessTime time.Time
+	// ChangeTime, if not zero, is when the entry or its details, such as its
+	// mode or owner, last changed.
+	ChangeTime time.Time
+}
+
+// statOf returns the extended information about info, from the filesystem
+// or from the operating system.
+func statOf(info fs.FileInfo) (Stat, bool) {
+	if st, ok := info.Sys().(*Stat); ok && st != nil {
+		return *st, true
+	}
+	return sysStat(info)
+}
+
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	st, ok := statOf(info)
+	return st.Device, ok
+}
+
+// diskUsage returns the number of bytes allocated for the file.
+func diskUsage(info fs.FileInfo) int64 {
+	if st, ok := statOf(info); ok {
+		return st.DiskUsage
+	}
+	return info.Size()
+}
+
+// accessTime returns when the file was last accessed.
+func accessTime(info fs.FileInfo) (time.Time, bool) {
+	st, ok := statOf(info)
+	return st.AccessTime, ok && !st.AccessTime.IsZero()
+}
+
+// changeTime returns when the file or its details last changed, or the zero
+// time if that isn't known.
+func changeTime(info fs.FileInfo) time.Time {
+	st, _ := statOf(info)
+	return st.ChangeTime
+}
+
+// owner returns the user and group owning the file.
+func owner(info fs.FileInfo) (uid, gid uint32, ok bool) {
+	st, ok := statOf(info)
+	return st.UID, st.GID, ok
+}
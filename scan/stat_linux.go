@@ -0,0 +1,49 @@
+package scan
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// Magic numbers of the pseudo filesystems skipped by --exclude-kernfs, as
+// found in linux/magic.h.
+var kernfsMagic = map[int64]bool{
+	0x42494e4d: true, // binfmt
+	0xcafe4a11: true, // bpf
+	0x27e0eb:   true, // cgroup
+	0x63677270: true, // cgroup2
+	0x64626720: true, // debug
+	0x1cd1:     true, // devpts
+	0x9fa0:     true, // proc
+	0x6165676c: true, // pstore
+	0x73636673: true, // security
+	0xf97cff8c: true, // selinux
+	0x62656572: true, // sys
+	0x74726163: true, // trace
+}
+
+// sysStat reads the extended information from the stat result behind info.
+func sysStat(info fs.FileInfo) (Stat, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return Stat{}, false
+	}
+	return Stat{
+		DiskUsage:  int64(st.Blocks) * 512,
+		Device:     uint64(st.Dev),
+		Inode:      uint64(st.Ino),
+		UID:        st.Uid,
+		GID:        st.Gid,
+		AccessTime: time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec)),
+		ChangeTime: time.Unix(int64(st.Ctim.Sec), int64(st.Ctim.Nsec)),
+	}, true
+}
+
+func isKernfs(path string) bool {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return false
+	}
+	return kernfsMagic[int64(fs.Type)]
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package scan
+
+import "io/fs"
+
+func sysStat(info fs.FileInfo) (Stat, bool) {
+	return Stat{}, false
+}
+
+func isKernfs(path string) bool {
+	return false
+}
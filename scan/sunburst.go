@@ -0,0 +1,319 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+)
+
+// Layout of the static SVG chart.
+const (
+	chartSize  = 800
+	chartDepth = 6
+	// minArc is the smallest angle, in radians, drawn in the SVG chart.
+	minArc = 0.002
+)
+
+// ChartOptions controls ExportSVG and ExportHTML.
+type ChartOptions struct {
+	// Title is shown on top of the chart, e.g. the version of godu.
+	Title string
+	// ApparentSize sizes the chart by apparent size instead of disk usage.
+	ApparentSize bool
+	// MinFraction is the smallest share of the whole tree an entry needs to
+	// be kept in the HTML chart. Smaller entries of a folder are merged into
+	// a single one so huge trees still give a page of reasonable size.
+	MinFraction float64
+}
+
+// chartNode is an entry of the chart, with short JSON names since the HTML
+// chart embeds the whole tree.
+type chartNode struct {
+	Name     string      `json:"n"`
+	Size     int64       `json:"s"`
+	Dir      bool        `json:"d,omitempty"`
+	Children []chartNode `json:"c,omitempty"`
+}
+
+// scale returns what the sizes of the children of node are a share of. That
+// is the size of node, unless its children add up to more, as the content of
+// a compressed archive does, in which case they would overlap its siblings.
+func (node chartNode) scale() int64 {
+	var sum int64
+	for _, c := range node.Children {
+		sum += c.Size
+	}
+	if sum > node.Size {
+		return sum
+	}
+	return node.Size
+}
+
+// buildChart converts folder, leaving out excluded entries and merging the
+// ones smaller than min bytes.
+func buildChart(folder Folder, apparent bool, min float64) chartNode {
+	size := func(disk, app int64) int64 {
+		if apparent {
+			return app
+		}
+		return disk
+	}
+	node := chartNode{Name: folder.Name, Size: size(folder.Size, folder.ApparentSize), Dir: true}
+	var small, count int64
+	for _, f := range folder.Folders {
+		if f.Excluded {
+			continue
+		}
+		if s := size(f.Size, f.ApparentSize); float64(s) < min {
+			small += s
+			count++
+			continue
+		}
+		node.Children = append(node.Children, buildChart(f, apparent, min))
+	}
+	for _, f := range folder.Files {
+		if f.Excluded {
+			continue
+		}
+		if s := size(f.Size, f.ApparentSize); float64(s) < min {
+			small += s
+			count++
+			continue
+		}
+		node.Children = append(node.Children, chartNode{Name: f.Name, Size: size(f.Size, f.ApparentSize)})
+	}
+	if small > 0 {
+		node.Children = append(node.Children, chartNode{Name: fmt.Sprintf("(%d smaller items)", count), Size: small})
+	}
+	return node
+}
+
+// ExportSVG writes root as a static sunburst chart: the root in the middle
+// and every ring one level further down, each entry spanning an angle
+// proportional to its size. Hovering an entry shows its path and size.
+func ExportSVG(w io.Writer, root Folder, opts ChartOptions) error {
+	node := buildChart(root, opts.ApparentSize, 0)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-family="sans-serif">`+"\n",
+		chartSize, chartSize+40, chartSize, chartSize+40)
+	fmt.Fprintf(&b, "<text x=\"%d\" y=\"24\" text-anchor=\"middle\" font-size=\"16\">%s</text>\n",
+		chartSize/2, html.EscapeString(opts.Title+" "+root.Path))
+	fmt.Fprintf(&b, "<g transform=\"translate(%d,%d)\" stroke=\"#fff\" stroke-width=\"0.5\">\n", chartSize/2, chartSize/2+40)
+
+	ring := float64(chartSize/2-10) / (chartDepth + 1)
+	fmt.Fprintf(&b, "<circle r=\"%.2f\" fill=\"#ddd\"><title>%s\n%s</title></circle>\n",
+		ring, html.EscapeString(root.Path), PrettyPrintSize(node.Size))
+	if node.scale() > 0 {
+		writeArcs(&b, node, root.Path, 0, 2*math.Pi, 1, 0, ring, node.Size)
+	}
+	b.WriteString("</g>\n</svg>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("error exporting %s: %w", root.Path, err)
+	}
+	return nil
+}
+
+// writeArcs draws the children of node between the angles a0 and a1 on
+// ring depth. hue is inherited from the top-level ancestor.
+func writeArcs(b *strings.Builder, node chartNode, path string, a0, a1 float64, depth int, hue, ring float64, total int64) {
+	scale := node.scale()
+	if depth > chartDepth || scale <= 0 {
+		return
+	}
+	start := a0
+	for _, c := range node.Children {
+		span := (a1 - a0) * float64(c.Size) / float64(scale)
+		end := start + span
+		if span >= minArc {
+			h := hue
+			if depth == 1 {
+				h = (start + span/2) / (2 * math.Pi) * 360
+			}
+			light := 40 + 7*depth
+			sat := 60
+			if !c.Dir {
+				sat = 25
+			}
+			p := path + "/" + c.Name
+			fmt.Fprintf(b, "<path d=\"%s\" fill=\"hsl(%.0f,%d%%,%d%%)\"><title>%s\n%s (%.1f%%)</title></path>\n",
+				arcPath(ring*float64(depth), ring*float64(depth+1), start, end), h, sat, light,
+				html.EscapeString(p), PrettyPrintSize(c.Size), float64(c.Size)/float64(total)*100)
+			if c.Dir {
+				writeArcs(b, c, p, start, end, depth+1, h, ring, total)
+			}
+		}
+		start = end
+	}
+}
+
+// arcPath returns the outline of the ring segment between the radii r0 and
+// r1 and the angles a0 and a1, measured clockwise from 12 o'clock.
+func arcPath(r0, r1, a0, a1 float64) string {
+	// a full circle can't be drawn with a single arc
+	if a1-a0 > 2*math.Pi-1e-4 {
+		a1 = a0 + 2*math.Pi - 1e-4
+	}
+	large := 0
+	if a1-a0 > math.Pi {
+		large = 1
+	}
+	point := func(r, a float64) string {
+		return fmt.Sprintf("%.2f,%.2f", r*math.Sin(a), -r*math.Cos(a))
+	}
+	return fmt.Sprintf("M%sL%sA%.2f,%.2f 0 %d 1 %sL%sA%.2f,%.2f 0 %d 0 %sZ",
+		point(r0, a0), point(r1, a0), r1, r1, large, point(r1, a1),
+		point(r0, a1), r0, r0, large, point(r0, a0))
+}
+
+// ExportHTML writes root as a standalone HTML page with a zoomable sunburst
+// chart. Everything the page needs is inline, so it works offline.
+func ExportHTML(w io.Writer, root Folder, opts ChartOptions) error {
+	min := 0.0
+	if opts.MinFraction > 0 {
+		size := root.Size
+		if opts.ApparentSize {
+			size = root.ApparentSize
+		}
+		min = float64(size) * opts.MinFraction
+	}
+	node := buildChart(root, opts.ApparentSize, min)
+	node.Name = root.Path
+	data, err := json.Marshal(node)
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %w", root.Path, err)
+	}
+	// json.Marshal escapes <, > and &, so the data is safe inside <script>
+	err = chartTemplate.Execute(w, map[string]string{
+		"Title": html.EscapeString(opts.Title + " " + root.Path),
+		"Data":  string(data),
+	})
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %w", root.Path, err)
+	}
+	return nil
+}
+
+var chartTemplate = template.Must(template.New("chart").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; padding: 1em; background: #fafafa; color: #222; }
+#crumbs { margin-bottom: .5em; }
+#crumbs a { color: #25a065; cursor: pointer; text-decoration: underline; }
+#chart path { stroke: #fff; stroke-width: .5; cursor: pointer; }
+#chart path:hover { opacity: .8; }
+#tip { position: fixed; pointer-events: none; background: #222; color: #fff; padding: .3em .6em; border-radius: 4px; font-size: 13px; display: none; white-space: pre; }
+</style>
+</head>
+<body>
+<h3>{{.Title}}</h3>
+<div id="crumbs"></div>
+<svg id="chart" width="800" height="800" viewBox="-400 -400 800 800"></svg>
+<div id="tip"></div>
+<script>
+var data = {{.Data}};
+var depth = 6, radius = 390, ring = radius / (depth + 1);
+var svg = document.getElementById("chart");
+var tip = document.getElementById("tip");
+var crumbs = document.getElementById("crumbs");
+
+function link(node, parent) {
+  node.parent = parent;
+  (node.c || []).forEach(function (c) { link(c, node); });
+}
+link(data, null);
+
+function path(node) {
+  var parts = [];
+  for (var n = node; n; n = n.parent) parts.unshift(n.n);
+  return parts.join("/").replace(/^\/\//, "/");
+}
+
+function human(size) {
+  var units = ["", "K", "M", "G", "T"], i = 0;
+  while (size > 1024 && i < units.length - 1) { size /= 1024; i++; }
+  return (i ? size.toFixed(1) : size) + units[i];
+}
+
+function point(r, a) {
+  return (r * Math.sin(a)).toFixed(2) + "," + (-r * Math.cos(a)).toFixed(2);
+}
+
+function arc(r0, r1, a0, a1) {
+  if (a1 - a0 > 2 * Math.PI - 1e-4) a1 = a0 + 2 * Math.PI - 1e-4;
+  var large = a1 - a0 > Math.PI ? 1 : 0;
+  return "M" + point(r0, a0) + "L" + point(r1, a0) +
+    "A" + r1 + "," + r1 + " 0 " + large + " 1 " + point(r1, a1) +
+    "L" + point(r0, a1) + "A" + r0 + "," + r0 + " 0 " + large + " 0 " + point(r0, a0) + "Z";
+}
+
+function shape(tag, attrs, node, total) {
+  var el = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  for (var k in attrs) el.setAttribute(k, attrs[k]);
+  el.addEventListener("mousemove", function (e) {
+    tip.style.display = "block";
+    tip.style.left = (e.clientX + 12) + "px";
+    tip.style.top = (e.clientY + 12) + "px";
+    tip.textContent = path(node) + "\n" + human(node.s) + " (" + (node.s / total * 100).toFixed(1) + "%)";
+  });
+  el.addEventListener("mouseleave", function () { tip.style.display = "none"; });
+  svg.appendChild(el);
+  return el;
+}
+
+function draw(root) {
+  while (svg.firstChild) svg.removeChild(svg.firstChild);
+  tip.style.display = "none";
+  var center = shape("circle", { r: ring, fill: "#ddd" }, root, root.s || 1);
+  center.style.cursor = root.parent ? "pointer" : "default";
+  center.addEventListener("click", function () { if (root.parent) draw(root.parent); });
+
+  // children may add up to more than their folder, e.g. in an archive
+  function arcs(node, a0, a1, level, hue) {
+    var scale = (node.c || []).reduce(function (sum, c) { return sum + c.s; }, 0);
+    if (scale < node.s) scale = node.s;
+    if (level > depth || !scale) return;
+    var start = a0;
+    (node.c || []).forEach(function (c) {
+      var span = (a1 - a0) * c.s / scale, end = start + span;
+      if (span >= 0.002) {
+        var h = level == 1 ? (start + span / 2) / (2 * Math.PI) * 360 : hue;
+        var el = shape("path", {
+          d: arc(ring * level, ring * (level + 1), start, end),
+          fill: "hsl(" + h.toFixed(0) + "," + (c.d ? 60 : 25) + "%," + (40 + 7 * level) + "%)"
+        }, c, root.s);
+        if (c.d) {
+          el.addEventListener("click", function () { draw(c); });
+          arcs(c, start, end, level + 1, h);
+        }
+      }
+      start = end;
+    });
+  }
+  arcs(root, 0, 2 * Math.PI, 1, 0);
+
+  crumbs.textContent = "";
+  var chain = [];
+  for (var n = root; n; n = n.parent) chain.unshift(n);
+  chain.forEach(function (n, i) {
+    if (i) crumbs.appendChild(document.createTextNode(" / "));
+    var a = document.createElement(n === root ? "span" : "a");
+    a.textContent = n.n;
+    if (n !== root) a.addEventListener("click", function () { draw(n); });
+    crumbs.appendChild(a);
+  });
+  crumbs.appendChild(document.createTextNode("  " + human(root.s)));
+}
+
+draw(data);
+</script>
+</body>
+</html>
+`))
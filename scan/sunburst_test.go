@@ -0,0 +1,124 @@
+package scan
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// chartFS has names that need escaping in HTML.
+func chartFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a&b.txt":       {Data: make([]byte, 300)},
+		"<i>.bin":       {Data: make([]byte, 100)},
+		`sub "q"/x<y>`:  {Data: make([]byte, 200)},
+		`sub "q"/z.txt`: {Data: make([]byte, 400)},
+	}
+}
+
+// arcRE matches an arc of the SVG chart, capturing the outer corner at its
+// start angle, whether it is longer than half a turn, the outer corner at
+// its end angle and the path in its title.
+var arcRE = regexp.MustCompile(`<path d="M[-\d.]+,[-\d.]+L([-\d.]+),([-\d.]+)A[\d.]+,[\d.]+ 0 ([01]) 1 ([-\d.]+),([-\d.]+)L[^"]*" fill="[^"]*"><title>([^\n]*)\n`)
+
+// angle returns the angle of the point x,y of the chart, clockwise from 12
+// o'clock.
+func angle(t *testing.T, x, y string) float64 {
+	t.Helper()
+	fx, err := strconv.ParseFloat(x, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fy, err := strconv.ParseFloat(y, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return math.Atan2(fx, -fy)
+}
+
+func TestExportSVG(t *testing.T) {
+	root, err := CreateFileTree(".", ScanOptions{FS: chartFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := ExportSVG(&b, root, ChartOptions{Title: "<godu>", ApparentSize: true}); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+
+	sizes := map[string]int64{
+		"./a&b.txt":       300,
+		"./<i>.bin":       100,
+		`./sub "q"`:       600,
+		`./sub "q"/x<y>`:  200,
+		`./sub "q"/z.txt`: 400,
+	}
+	arcs := arcRE.FindAllStringSubmatch(svg, -1)
+	if len(arcs) != len(sizes) {
+		t.Fatalf("found %d arcs, want %d:\n%s", len(arcs), len(sizes), svg)
+	}
+	// every ring adds up to the share of the root its entries have
+	rings := map[int]float64{}
+	for _, arc := range arcs {
+		path := html.UnescapeString(arc[6])
+		size, ok := sizes[path]
+		if !ok {
+			t.Errorf("unexpected arc %q", path)
+			continue
+		}
+		span := angle(t, arc[4], arc[5]) - angle(t, arc[1], arc[2])
+		if span <= 0 {
+			span += 2 * math.Pi
+		}
+		if want := 2 * math.Pi * float64(size) / 1000; math.Abs(span-want) > 1e-3 {
+			t.Errorf("arc of %s spans %.4f, want %.4f", path, span, want)
+		}
+		if (span > math.Pi) != (arc[3] == "1") {
+			t.Errorf("arc of %s has the wrong large arc flag %s", path, arc[3])
+		}
+		rings[strings.Count(path, "/")] += span
+	}
+	for ring, want := range map[int]float64{1: 2 * math.Pi, 2: 2 * math.Pi * 0.6} {
+		if math.Abs(rings[ring]-want) > 1e-3 {
+			t.Errorf("ring %d spans %.4f, want %.4f", ring, rings[ring], want)
+		}
+	}
+
+	for _, name := range []string{"<godu>", "<i>", "x<y>", "a&b", `"q"`} {
+		if strings.Contains(svg, name) {
+			t.Errorf("%s isn't escaped", name)
+		}
+	}
+	for _, name := range []string{"&lt;godu&gt;", "&lt;i&gt;.bin", "a&amp;b.txt"} {
+		if !strings.Contains(svg, name) {
+			t.Errorf("%s is missing", name)
+		}
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	root, err := CreateFileTree(".", ScanOptions{FS: chartFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := ExportHTML(&b, root, ChartOptions{Title: "<godu>", ApparentSize: true}); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, name := range []string{"<godu>", "<i>", "x<y>", "a&b"} {
+		if strings.Contains(page, name) {
+			t.Errorf("%s isn't escaped", name)
+		}
+	}
+	for _, name := range []string{"<title>&lt;godu&gt; .</title>", `\u003ci\u003e.bin`, `a\u0026b.txt`, `"s":1000`} {
+		if !strings.Contains(page, name) {
+			t.Errorf("%s is missing", name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package scan
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+)
+
+// Entry is a file or folder taken out of the tree, e.g. for a list of the
+// largest files.
+type Entry struct {
+	// ID is the entry in the Tree it was taken from, see Tree.Contains.
+	ID           NodeID    `json:"-"`
+	Path         string    `json:"path"`
+	Size         int64     `json:"dsize"`
+	ApparentSize int64     `json:"asize"`
+	ModTime      time.Time `json:"mtime"`
+	UID          uint32    `json:"uid"`
+	GID          uint32    `json:"gid"`
+	IsDir        bool      `json:"dir"`
+}
+
+// Entry returns the entry id of t.
+func (t *Tree) Entry(id NodeID) Entry {
+	return Entry{
+		ID:           id,
+		Path:         t.Path(id),
+		Size:         t.Size(id),
+		ApparentSize: t.ApparentSize(id),
+		ModTime:      t.ModTime(id),
+		UID:          t.UID(id),
+		GID:          t.GID(id),
+		IsDir:        t.IsDir(id),
+	}
+}
+
+// entryHeap is a min-heap on the size that is being ranked, so the smallest
+// of the largest entries found so far is the one dropped.
+type entryHeap struct {
+	entries  []Entry
+	apparent bool
+}
+
+func (h *entryHeap) size(i int) int64 {
+	if h.apparent {
+		return h.entries[i].ApparentSize
+	}
+	return h.entries[i].Size
+}
+
+func (h *entryHeap) Len() int           { return len(h.entries) }
+func (h *entryHeap) Less(i, j int) bool { return h.size(i) < h.size(j) }
+func (h *entryHeap) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *entryHeap) Push(x interface{}) { h.entries = append(h.entries, x.(Entry)) }
+func (h *entryHeap) Pop() interface{} {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
+
+// wants reports whether an entry of the given size would be kept among the
+// n largest, so that entries that wouldn't be aren't built at all.
+func (h *entryHeap) wants(size int64, n int) bool {
+	return h.Len() < n || size > h.size(0)
+}
+
+// add keeps e if it is among the n largest entries seen so far.
+func (h *entryHeap) add(e Entry, n int) {
+	if h.Len() < n {
+		heap.Push(h, e)
+		return
+	}
+	size := e.Size
+	if h.apparent {
+		size = e.ApparentSize
+	}
+	if size <= h.size(0) {
+		return
+	}
+	h.entries[0] = e
+	heap.Fix(h, 0)
+}
+
+// sorted returns the entries, largest first.
+func (h *entryHeap) sorted() []Entry {
+	entries := h.entries
+	sort.Slice(entries, func(i, j int) bool {
+		if h.size(i) != h.size(j) {
+			return h.size(i) > h.size(j)
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// Largest returns the n largest files below root, largest first, ranked by
+// apparent size or disk usage. With dirs, folders below root compete with the
+// files. Only n entries are held at any time, so it works on trees of any
+// size. Excluded entries are skipped.
+func Largest(root Folder, n int, dirs, apparent bool) []Entry {
+	t := FromFolder(root)
+	return t.Largest(t.Root(), n, dirs, apparent)
+}
+
+// Largest returns the n largest files below the folder id, like Largest does
+// for a Folder.
+func (t *Tree) Largest(id NodeID, n int, dirs, apparent bool) []Entry {
+	if n <= 0 {
+		return []Entry{}
+	}
+	h := &entryHeap{apparent: apparent}
+	size := t.Size
+	if apparent {
+		size = t.ApparentSize
+	}
+	t.walkBelow(id, func(c NodeID) bool {
+		if t.Excluded(c) {
+			return false
+		}
+		if (dirs || !t.IsDir(c)) && h.wants(size(c), n) {
+			h.add(t.Entry(c), n)
+		}
+		return true
+	})
+	return h.sorted()
+}
@@ -0,0 +1,525 @@
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+Tree stores a scanned directory in a few flat slices instead of nested Folder
+values. Every entry is a fixed size node that refers to its parent, first and
+last child and next sibling by index, names are interned so that common ones
+such as "index.js" or ".git" are stored once, and paths and human readable
+sizes are only computed when asked for. A File takes about 160 bytes plus its
+path, directory, name and size strings, a node about 100 bytes plus its share
+of the names, which roughly halves the memory needed for large scans.
+
+Nodes are only ever appended. Removing an entry unlinks it from the tree but
+its nodes stay allocated until the Tree is dropped.
+*/
+
+// NodeID identifies an entry of a Tree.
+type NodeID int32
+
+// NoNode is the parent of the root and the child of an empty folder.
+const NoNode NodeID = -1
+
+const (
+	nodeDir uint8 = 1 << iota
+	nodeExcluded
+	// nodeArchive is an archive file listed as a folder.
+	nodeArchive
+	// nodeInArchive is an entry inside an archive, which isn't on disk.
+	nodeInArchive
+	// nodeUnread is a folder whose entries weren't listed, because it
+	// couldn't be read or is on another filesystem.
+	nodeUnread
+	// nodeRemoved is an entry taken out of its folder by Remove or Replace.
+	nodeRemoved
+)
+
+type node struct {
+	name        uint32
+	parent      NodeID
+	firstChild  NodeID
+	lastChild   NodeID
+	nextSibling NodeID
+	flags       uint8
+	mode        os.FileMode
+	uid, gid    uint32
+	size        int64
+	apparent    int64
+	items       int64
+	modTime     int64
+	changeTime  int64
+	accessTime  int64
+	hash        uint64
+}
+
+// Tree is a scanned directory, see NewTree and ScanTree.
+type Tree struct {
+	nodes     []node
+	names     []string
+	nameIndex map[string]uint32
+	// fsys is the filesystem the tree was scanned from, see ScanOptions.FS,
+	// or nil for the local disk.
+	fsys fs.FS
+}
+
+// NewTree returns a tree holding just a root folder at path.
+func NewTree(path string) *Tree {
+	t := &Tree{nameIndex: map[string]uint32{}}
+	t.add(NoNode, path, true)
+	return t
+}
+
+// intern returns the index of name in t.names, adding it if needed.
+func (t *Tree) intern(name string) uint32 {
+	if i, ok := t.nameIndex[name]; ok {
+		return i
+	}
+	i := uint32(len(t.names))
+	t.names = append(t.names, name)
+	t.nameIndex[name] = i
+	return i
+}
+
+// add appends a new entry as the last child of parent.
+func (t *Tree) add(parent NodeID, name string, dir bool) NodeID {
+	id := NodeID(len(t.nodes))
+	n := node{
+		name:        t.intern(name),
+		parent:      parent,
+		firstChild:  NoNode,
+		lastChild:   NoNode,
+		nextSibling: NoNode,
+	}
+	if dir {
+		n.flags |= nodeDir
+	}
+	t.nodes = append(t.nodes, n)
+	if parent != NoNode {
+		t.link(parent, id)
+	}
+	return id
+}
+
+// setInfo copies what is known about an entry from info.
+func (t *Tree) setInfo(id NodeID, info fs.FileInfo) {
+	n := &t.nodes[id]
+	n.mode = info.Mode()
+	n.size = diskUsage(info)
+	n.apparent = info.Size()
+	n.modTime = unixNanos(info.ModTime())
+	n.changeTime = unixNanos(changeTime(info))
+	n.uid, n.gid, _ = owner(info)
+}
+
+// describes reports whether info shows the entry n was made from with
+// setInfo, unchanged. A file can't be rewritten without changing its
+// modification or change time.
+func (n *node) describes(info fs.FileInfo) bool {
+	return n.mode == info.Mode() && n.size == diskUsage(info) && n.apparent == info.Size() &&
+		n.modTime == unixNanos(info.ModTime()) && n.changeTime == unixNanos(changeTime(info))
+}
+
+// Len returns the number of entries, including removed ones.
+func (t *Tree) Len() int { return len(t.nodes) }
+
+// Root returns the scanned directory.
+func (t *Tree) Root() NodeID { return 0 }
+
+// Name returns the name of an entry. The name of the root is its full path.
+func (t *Tree) Name(id NodeID) string { return t.names[t.nodes[id].name] }
+
+// Parent returns the folder holding an entry, or NoNode for the root.
+func (t *Tree) Parent(id NodeID) NodeID { return t.nodes[id].parent }
+
+// Path returns the full path of an entry.
+func (t *Tree) Path(id NodeID) string {
+	var parts []string
+	for ; id != NoNode; id = t.nodes[id].parent {
+		parts = append(parts, t.Name(id))
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return filepath.Join(parts...)
+}
+
+// Children returns the entries of a folder in the order they were scanned.
+func (t *Tree) Children(id NodeID) []NodeID {
+	children := []NodeID{}
+	for c := t.nodes[id].firstChild; c != NoNode; c = t.nodes[c].nextSibling {
+		children = append(children, c)
+	}
+	return children
+}
+
+// Child returns the entry called name in a folder.
+func (t *Tree) Child(id NodeID, name string) (NodeID, bool) {
+	for c := t.nodes[id].firstChild; c != NoNode; c = t.nodes[c].nextSibling {
+		if t.Name(c) == name {
+			return c, true
+		}
+	}
+	return NoNode, false
+}
+
+// Lookup returns the entry at path.
+func (t *Tree) Lookup(path string) (NodeID, bool) {
+	id, root := t.Root(), t.Name(t.Root())
+	path = filepath.Clean(path)
+	if path == root {
+		return id, true
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return NoNode, false
+	}
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		var ok bool
+		if id, ok = t.Child(id, name); !ok {
+			return NoNode, false
+		}
+	}
+	return id, true
+}
+
+// Contains reports whether the entry id is still part of t, that is neither
+// it nor a folder above it was removed or replaced since id was taken from t.
+// It only takes the depth of id, unlike looking up its path.
+func (t *Tree) Contains(id NodeID) bool {
+	if id < 0 || int(id) >= len(t.nodes) {
+		return false
+	}
+	for ; id != NoNode; id = t.nodes[id].parent {
+		if t.nodes[id].flags&nodeRemoved != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Walk calls fn for id and every entry below it, parents before their
+// children. Returning false from fn skips the entries below that one.
+func (t *Tree) Walk(id NodeID, fn func(NodeID) bool) {
+	if !fn(id) {
+		return
+	}
+	for c := t.nodes[id].firstChild; c != NoNode; c = t.nodes[c].nextSibling {
+		t.Walk(c, fn)
+	}
+}
+
+// walkBelow calls fn like Walk does, for the entries below id but not for
+// id itself.
+func (t *Tree) walkBelow(id NodeID, fn func(NodeID) bool) {
+	for c := t.nodes[id].firstChild; c != NoNode; c = t.nodes[c].nextSibling {
+		t.Walk(c, fn)
+	}
+}
+
+// IsDir reports whether an entry is a folder.
+func (t *Tree) IsDir(id NodeID) bool { return t.nodes[id].flags&nodeDir != 0 }
+
+// Excluded reports whether an entry matched an exclude pattern. Excluded
+// entries are kept in the tree but don't count towards the totals.
+func (t *Tree) Excluded(id NodeID) bool { return t.nodes[id].flags&nodeExcluded != 0 }
+
+// IsArchive reports whether an entry is an archive file whose content is
+// listed as if it were a folder, see ScanOptions.Archives.
+func (t *Tree) IsArchive(id NodeID) bool { return t.nodes[id].flags&nodeArchive != 0 }
+
+// InArchive reports whether an entry is part of the content of an archive
+// rather than a file on disk.
+func (t *Tree) InArchive(id NodeID) bool { return t.nodes[id].flags&nodeInArchive != 0 }
+
+// Local reports whether t was scanned from the local disk rather than from
+// ScanOptions.FS or a tar stream, so that its paths can be deleted, updated
+// or scanned again.
+func (t *Tree) Local() bool { return t.fsys == nil }
+
+// Size returns the disk usage of an entry, including everything below it.
+func (t *Tree) Size(id NodeID) int64 { return t.nodes[id].size }
+
+// ApparentSize returns the apparent size of an entry, including everything
+// below it.
+func (t *Tree) ApparentSize(id NodeID) int64 { return t.nodes[id].apparent }
+
+// HumanSize returns the disk usage of an entry for display.
+func (t *Tree) HumanSize(id NodeID) string { return PrettyPrintSize(t.nodes[id].size) }
+
+// Items returns the number of entries below a folder.
+func (t *Tree) Items(id NodeID) int64 { return t.nodes[id].items }
+
+// Mode returns the file mode of an entry.
+func (t *Tree) Mode(id NodeID) os.FileMode { return t.nodes[id].mode }
+
+// ModTime returns when an entry was last modified.
+func (t *Tree) ModTime(id NodeID) time.Time { return unixTime(t.nodes[id].modTime) }
+
+// AccessTime returns when a file was last accessed, which is only recorded
+// in extended mode.
+func (t *Tree) AccessTime(id NodeID) time.Time { return unixTime(t.nodes[id].accessTime) }
+
+// UID returns the user owning an entry.
+func (t *Tree) UID(id NodeID) uint32 { return t.nodes[id].uid }
+
+// GID returns the group of an entry.
+func (t *Tree) GID(id NodeID) uint32 { return t.nodes[id].gid }
+
+// Hash returns the content hash of an entry, see FindDuplicates and
+// IdenticalFolders, or 0. The hash of a folder is cleared when anything
+// below it changes.
+func (t *Tree) Hash(id NodeID) uint64 { return t.nodes[id].hash }
+
+func unixTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// total adds up the sizes and item counts of the children of a folder on
+// top of its own size.
+func (t *Tree) total(id NodeID) {
+	n := &t.nodes[id]
+	for c := n.firstChild; c != NoNode; c = t.nodes[c].nextSibling {
+		child := &t.nodes[c]
+		if child.flags&nodeExcluded != 0 {
+			continue
+		}
+		n.size += child.size
+		n.apparent += child.apparent
+		n.items += child.items + 1
+	}
+}
+
+// Folder returns the entry id, which has to be a folder, and everything
+// below it as a Folder. Like a scanned one, the root has no HighDir.
+func (t *Tree) Folder(id NodeID) Folder {
+	highDir := ""
+	if p := t.Parent(id); p != NoNode {
+		highDir = t.Path(p)
+	}
+	return t.folder(id, highDir, t.Path(id))
+}
+
+func (t *Tree) folder(id NodeID, highDir, path string) Folder {
+	n := &t.nodes[id]
+	name := t.Name(id)
+	if n.parent == NoNode {
+		name = filepath.Base(path)
+	}
+	f := Folder{
+		Path:         path,
+		HighDir:      highDir,
+		Name:         name,
+		Size:         n.size,
+		ApparentSize: n.apparent,
+		HumanSize:    PrettyPrintSize(n.size),
+		Mode:         n.mode,
+		ModTime:      unixTime(n.modTime),
+		UID:          n.uid,
+		GID:          n.gid,
+		Hash:         n.hash,
+		Excluded:     n.flags&nodeExcluded != 0,
+		Archive:      n.flags&nodeArchive != 0,
+		InArchive:    n.flags&nodeInArchive != 0,
+		Items:        n.items,
+		Files:        make([]File, 0),
+		Folders:      make([]Folder, 0),
+	}
+	for c := n.firstChild; c != NoNode; c = t.nodes[c].nextSibling {
+		child := &t.nodes[c]
+		childName := t.Name(c)
+		if child.flags&nodeDir != 0 {
+			f.Folders = append(f.Folders, t.folder(c, path, filepath.Join(path, childName)))
+			continue
+		}
+		f.Files = append(f.Files, File{
+			Path:         childName,
+			HighDir:      path,
+			Name:         childName,
+			Size:         child.size,
+			ApparentSize: child.apparent,
+			HumanSize:    PrettyPrintSize(child.size),
+			Mode:         child.mode,
+			ModTime:      unixTime(child.modTime),
+			AccessTime:   unixTime(child.accessTime),
+			UID:          child.uid,
+			GID:          child.gid,
+			Hash:         child.hash,
+			Excluded:     child.flags&nodeExcluded != 0,
+			InArchive:    child.flags&nodeInArchive != 0,
+		})
+	}
+	return f
+}
+
+// FromFolder stores folder and everything below it in a new Tree, e.g. an
+// imported one.
+func FromFolder(folder Folder) *Tree {
+	t := NewTree(folder.Path)
+	t.addFolder(t.Root(), folder)
+	return t
+}
+
+// addFolder copies the details of folder and its children into id.
+func (t *Tree) addFolder(id NodeID, folder Folder) {
+	n := &t.nodes[id]
+	n.mode = folder.Mode
+	n.size = folder.Size
+	n.apparent = folder.ApparentSize
+	n.items = folder.Items
+	n.modTime = unixNanos(folder.ModTime)
+	n.uid, n.gid = folder.UID, folder.GID
+	n.hash = folder.Hash
+	if folder.Excluded {
+		n.flags |= nodeExcluded
+	}
+	if folder.Archive {
+		n.flags |= nodeArchive
+	}
+	if folder.InArchive {
+		n.flags |= nodeInArchive
+	}
+	for _, f := range folder.Folders {
+		t.addFolder(t.add(id, f.Name, true), f)
+	}
+	for _, f := range folder.Files {
+		c := t.add(id, f.Name, false)
+		n := &t.nodes[c]
+		n.mode = f.Mode
+		n.size = f.Size
+		n.apparent = f.ApparentSize
+		n.modTime = unixNanos(f.ModTime)
+		n.accessTime = unixNanos(f.AccessTime)
+		n.uid, n.gid = f.UID, f.GID
+		n.hash = f.Hash
+		if f.Excluded {
+			n.flags |= nodeExcluded
+		}
+		if f.InArchive {
+			n.flags |= nodeInArchive
+		}
+	}
+}
+
+// Remove takes the entry id out of t, which has to be below the root, and
+// updates the totals of the folders above it.
+func (t *Tree) Remove(id NodeID) {
+	n := t.nodes[id]
+	t.unlink(id, NoNode)
+	if n.flags&nodeExcluded == 0 {
+		t.propagate(n.parent, -n.size, -n.apparent, -n.items-1)
+	}
+}
+
+// Replace swaps the entry id for the root of sub, e.g. a rescan of the same
+// folder, and returns its new ID. The new entry keeps the name, position and
+// exclusion of the old one. Replacing the root replaces the whole tree, so
+// IDs taken from t before are no longer valid.
+func (t *Tree) Replace(id NodeID, sub *Tree) NodeID {
+	old := t.nodes[id]
+	if old.parent == NoNode {
+		*t = *sub
+		return t.Root()
+	}
+	c := t.graft(old.parent, t.Name(id), sub, sub.Root())
+	t.nodes[c].flags |= old.flags & nodeExcluded
+	t.unlink(id, c)
+	if old.flags&nodeExcluded == 0 {
+		n := t.nodes[c]
+		t.propagate(old.parent, n.size-old.size, n.apparent-old.apparent, n.items-old.items)
+	}
+	return c
+}
+
+// graft copies the entry sid of sub and everything below it into t as an
+// entry called name of parent, without linking it to its siblings.
+func (t *Tree) graft(parent NodeID, name string, sub *Tree, sid NodeID) NodeID {
+	id := NodeID(len(t.nodes))
+	n := sub.nodes[sid]
+	n.name = t.intern(name)
+	n.parent = parent
+	n.firstChild, n.lastChild, n.nextSibling = NoNode, NoNode, NoNode
+	t.nodes = append(t.nodes, n)
+	for c := sub.nodes[sid].firstChild; c != NoNode; c = sub.nodes[c].nextSibling {
+		t.link(id, t.graft(id, sub.Name(c), sub, c))
+	}
+	return id
+}
+
+// link makes the unlinked entry id the last child of parent.
+func (t *Tree) link(parent, id NodeID) {
+	p := &t.nodes[parent]
+	if p.lastChild == NoNode {
+		p.firstChild = id
+	} else {
+		t.nodes[p.lastChild].nextSibling = id
+	}
+	p.lastChild = id
+}
+
+// unlink takes id out of the children of its parent, putting with in its
+// place unless it is NoNode, and marks it as removed. The parent of id is
+// kept so that its path can still be found.
+func (t *Tree) unlink(id, with NodeID) {
+	p := &t.nodes[t.nodes[id].parent]
+	next := t.nodes[id].nextSibling
+	if with != NoNode {
+		t.nodes[with].nextSibling = next
+		next = with
+	}
+	if p.firstChild == id {
+		p.firstChild = next
+	} else {
+		prev := p.firstChild
+		for t.nodes[prev].nextSibling != id {
+			prev = t.nodes[prev].nextSibling
+		}
+		t.nodes[prev].nextSibling = next
+	}
+	if p.lastChild == id {
+		if with != NoNode {
+			p.lastChild = with
+		} else {
+			// find the new last child
+			p.lastChild = NoNode
+			for c := p.firstChild; c != NoNode; c = t.nodes[c].nextSibling {
+				p.lastChild = c
+			}
+		}
+	}
+	t.nodes[id].nextSibling = NoNode
+	t.nodes[id].flags |= nodeRemoved
+}
+
+// propagate adds to the totals of id and the folders above it, stopping
+// after an excluded one since it isn't counted in its parent. The hashes of
+// the folders no longer match their content, so they are cleared.
+func (t *Tree) propagate(id NodeID, size, apparent, items int64) {
+	for id != NoNode {
+		n := &t.nodes[id]
+		n.size += size
+		n.apparent += apparent
+		n.items += items
+		n.hash = 0
+		if n.flags&nodeExcluded != 0 {
+			return
+		}
+		id = n.parent
+	}
+}
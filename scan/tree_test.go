@@ -0,0 +1,116 @@
+package scan
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"testing/fstest"
+)
+
+// benchmarkFS returns dirs folders of files files each.
+func benchmarkFS(dirs, files int) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for d := 0; d < dirs; d++ {
+		for f := 0; f < files; f++ {
+			fsys[fmt.Sprintf("dir%03d/file%04d.txt", d, f)] = &fstest.MapFile{Data: []byte("content")}
+		}
+	}
+	return fsys
+}
+
+// liveBytes returns how much memory the result of build keeps in use.
+func liveBytes(build func() interface{}) uint64 {
+	var before, after runtime.MemStats
+	collect := func(stats *runtime.MemStats) {
+		// the second collection frees what sync.Pools let go of in the first
+		runtime.GC()
+		runtime.GC()
+		runtime.ReadMemStats(stats)
+	}
+	collect(&before)
+	v := build()
+	collect(&after)
+	runtime.KeepAlive(v)
+	if after.HeapAlloc < before.HeapAlloc {
+		return 0
+	}
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+func benchmarkTree(b *testing.B) *Tree {
+	t, err := ScanTree(".", ScanOptions{FS: benchmarkFS(100, 100)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return t
+}
+
+// BenchmarkTree measures the Tree a scan builds, made from a Folder so that
+// reading the filesystem isn't counted.
+func BenchmarkTree(b *testing.B) {
+	t := benchmarkTree(b)
+	entries := t.Items(t.Root()) + 1
+	root := t.Folder(t.Root())
+	size := liveBytes(func() interface{} { return FromFolder(root) })
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FromFolder(root)
+	}
+	b.ReportMetric(float64(size)/float64(entries), "bytes/entry")
+}
+
+// BenchmarkFolder measures the same tree copied into a Folder.
+func BenchmarkFolder(b *testing.B) {
+	t := benchmarkTree(b)
+	entries := t.Items(t.Root()) + 1
+	size := liveBytes(func() interface{} {
+		root := t.Folder(t.Root())
+		return &root
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.Folder(t.Root())
+	}
+	b.ReportMetric(float64(size)/float64(entries), "bytes/entry")
+}
+
+func TestContains(t *testing.T) {
+	tree, err := ScanTree(".", ScanOptions{FS: testFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, _ := tree.Lookup("src")
+	du, _ := tree.Lookup("src/du.go")
+	out, _ := tree.Lookup("build/out.o")
+	build, _ := tree.Lookup("build")
+
+	tree.Remove(src)
+	sub, err := ScanTree("build", ScanOptions{FS: testFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newBuild := tree.Replace(build, sub)
+	newOut, _ := tree.Lookup("build/out.o")
+
+	for _, c := range []struct {
+		name string
+		id   NodeID
+		want bool
+	}{
+		{"root", tree.Root(), true},
+		{"removed folder", src, false},
+		{"file in a removed folder", du, false},
+		{"replaced folder", build, false},
+		{"file in a replaced folder", out, false},
+		{"replacement", newBuild, true},
+		{"file in the replacement", newOut, true},
+		{"out of range", NodeID(tree.Len()), false},
+		{"NoNode", NoNode, false},
+	} {
+		if got := tree.Contains(c.id); got != c.want {
+			t.Errorf("Contains(%s) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
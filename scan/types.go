@@ -0,0 +1,144 @@
+package scan
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sniffSize is how much of a file net/http.DetectContentType looks at.
+const sniffSize = 512
+
+// TypeGroup is the files of one kind below a folder, e.g. the ones with the
+// same extension or the same owner.
+type TypeGroup struct {
+	Name         string
+	Size         int64
+	ApparentSize int64
+	Files        []Entry
+}
+
+// Extension returns the lower-cased extension of name including the dot, or
+// "" if it has none. Dot files such as .bashrc have no extension.
+func Extension(name string) string {
+	ext := filepath.Ext(name)
+	if ext == name || ext == "." {
+		return ""
+	}
+	return strings.ToLower(ext)
+}
+
+// ByExtension groups the files below folder by their extension, largest
+// group first. Files without an extension are grouped under "".
+func ByExtension(folder Folder) []TypeGroup {
+	t := FromFolder(folder)
+	return t.ByExtension(t.Root())
+}
+
+// ByExtension groups the files below the folder id by their extension, like
+// ByExtension does for a Folder.
+func (t *Tree) ByExtension(id NodeID) []TypeGroup {
+	return t.groupFiles(id, func(c NodeID) string {
+		return Extension(t.Name(c))
+	})
+}
+
+// ByContentType groups the files below folder by the top-level MIME type of
+// their content, such as "video" or "text", largest group first. The type is
+// detected from the first bytes of every file, so this reads each of them.
+// Files that can't be read are grouped under "unknown" and empty ones under
+// "empty".
+func ByContentType(folder Folder) []TypeGroup {
+	t := FromFolder(folder)
+	return t.ByContentType(t.Root())
+}
+
+// ByContentType groups the files below the folder id by the type of their
+// content, like ByContentType does for a Folder. Use Files and
+// FileList.ByContentType to read them while t is in use.
+func (t *Tree) ByContentType(id NodeID) []TypeGroup {
+	return t.Files(id).ByContentType()
+}
+
+// ByContentType groups the files of l by the type of their content, see
+// ByContentType.
+func (l *FileList) ByContentType() []TypeGroup {
+	groups := typeGroups{}
+	for _, f := range l.files {
+		groups.add(l.contentType(f), f.entry)
+	}
+	return groups.sorted()
+}
+
+// contentType returns the top-level MIME type of f, or "empty" or "unknown".
+func (l *FileList) contentType(f listedFile) string {
+	switch {
+	case f.entry.ApparentSize == 0:
+		return "empty"
+	case !f.mode.IsRegular() || f.inArchive:
+		return "unknown"
+	}
+	file, err := l.open(&f)
+	if err != nil {
+		return "unknown"
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "unknown"
+	}
+	mime := http.DetectContentType(buf[:n])
+	if i := strings.Index(mime, "/"); i >= 0 {
+		mime = mime[:i]
+	}
+	return mime
+}
+
+// groupFiles groups the files below the folder id that aren't excluded by
+// the name kind returns for them.
+func (t *Tree) groupFiles(id NodeID, kind func(NodeID) string) []TypeGroup {
+	groups := typeGroups{}
+	t.walkBelow(id, func(c NodeID) bool {
+		if t.Excluded(c) {
+			return false
+		}
+		if !t.IsDir(c) {
+			groups.add(kind(c), t.Entry(c))
+		}
+		return true
+	})
+	return groups.sorted()
+}
+
+// typeGroups collects files into groups by name.
+type typeGroups map[string]*TypeGroup
+
+func (groups typeGroups) add(name string, e Entry) {
+	g, ok := groups[name]
+	if !ok {
+		g = &TypeGroup{Name: name}
+		groups[name] = g
+	}
+	g.Size += e.Size
+	g.ApparentSize += e.ApparentSize
+	g.Files = append(g.Files, e)
+}
+
+// sorted returns the groups, largest first.
+func (groups typeGroups) sorted() []TypeGroup {
+	sorted := make([]TypeGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, *g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Size != sorted[j].Size {
+			return sorted[i].Size > sorted[j].Size
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,39 @@
+package scan
+
+import "errors"
+
+var (
+	// ErrWatchLimit is returned by Watcher.Add when the system doesn't allow
+	// watching any more folders. The folders watched so far keep working.
+	ErrWatchLimit = errors.New("watch limit reached")
+	// ErrWatchOverflow is returned by Watcher.Read when changes came in
+	// faster than they were read and some were lost.
+	ErrWatchOverflow = errors.New("too many changes, some were missed")
+	// ErrWatchUnsupported is returned by NewWatcher on systems where
+	// watching folders isn't available.
+	ErrWatchUnsupported = errors.New("watching folders isn't supported on this system")
+)
+
+// AddTree watches the folder id of t and every folder below it that was
+// scanned, and returns how many were added. It stops at ErrWatchLimit, while
+// folders that can't be watched for other reasons are skipped.
+func (w *Watcher) AddTree(t *Tree, id NodeID) (int, error) {
+	var (
+		added int
+		err   error
+	)
+	t.Walk(id, func(c NodeID) bool {
+		if err != nil || t.nodes[c].flags&(nodeDir|nodeExcluded|nodeArchive|nodeInArchive|nodeUnread) != nodeDir {
+			return false
+		}
+		if e := w.Add(t.Path(c)); e != nil {
+			if errors.Is(e, ErrWatchLimit) {
+				err = e
+			}
+			return false
+		}
+		added++
+		return true
+	})
+	return added, err
+}
@@ -0,0 +1,153 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchMask is the changes a Watcher asks inotify about.
+const watchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_ATTRIB |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_ONLYDIR | unix.IN_DONT_FOLLOW | unix.IN_EXCL_UNLINK
+
+// Watcher reports the entries that change in the folders it watches, using
+// inotify. Add and AddTree can be called while another goroutine is blocked
+// in Read.
+type Watcher struct {
+	fd   int
+	file *os.File
+	buf  []byte
+
+	mu    sync.Mutex
+	paths map[int]string
+	wds   map[string]int
+}
+
+// NewWatcher returns a Watcher that doesn't watch anything yet.
+func NewWatcher() (*Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err == unix.EMFILE {
+		return nil, ErrWatchLimit
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error watching folders: %w", err)
+	}
+	return &Watcher{
+		fd: fd,
+		// non-blocking, so that Close wakes up Read
+		file:  os.NewFile(uintptr(fd), "inotify"),
+		buf:   make([]byte, 64*1024),
+		paths: map[int]string{},
+		wds:   map[string]int{},
+	}, nil
+}
+
+// Add watches the entries of the folder dir, but not the folders below it.
+func (w *Watcher) Add(dir string) error {
+	wd, err := unix.InotifyAddWatch(w.fd, dir, watchMask)
+	if err == unix.ENOSPC {
+		return ErrWatchLimit
+	}
+	if err != nil {
+		return &os.PathError{Op: "watch", Path: dir, Err: err}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if old, ok := w.paths[wd]; ok {
+		delete(w.wds, old)
+	}
+	w.paths[wd] = dir
+	w.wds[dir] = wd
+	return nil
+}
+
+// Len returns the number of folders being watched.
+func (w *Watcher) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.paths)
+}
+
+// Read waits for changes and returns the paths of the entries that were
+// created, changed, removed or moved, each of them once. Folders moved away
+// are no longer watched, while new ones have to be added, e.g. with AddTree
+// after Tree.Update. It returns ErrWatchOverflow along with the paths it has
+// if changes were lost, and an error once the Watcher is closed.
+func (w *Watcher) Read() ([]string, error) {
+	n, err := w.file.Read(w.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var (
+		paths []string
+		seen  = map[string]bool{}
+	)
+	for off := 0; off+unix.SizeofInotifyEvent <= n; {
+		e := (*unix.InotifyEvent)(unsafe.Pointer(&w.buf[off]))
+		name := strings.TrimRight(string(w.buf[off+unix.SizeofInotifyEvent:off+unix.SizeofInotifyEvent+int(e.Len)]), "\x00")
+		off += unix.SizeofInotifyEvent + int(e.Len)
+
+		switch {
+		case e.Mask&unix.IN_Q_OVERFLOW != 0:
+			err = ErrWatchOverflow
+			continue
+		case e.Mask&unix.IN_IGNORED != 0:
+			w.forget(int(e.Wd))
+			continue
+		case name == "":
+			// about the watched folder itself, which its parent reports too
+			continue
+		case e.Mask&unix.IN_ISDIR != 0 && e.Mask&(unix.IN_MODIFY|unix.IN_ATTRIB) != 0:
+			// the content of a folder is watched on its own
+			continue
+		}
+		dir, ok := w.paths[int(e.Wd)]
+		if !ok {
+			continue
+		}
+		p := filepath.Join(dir, name)
+		if e.Mask&unix.IN_MOVED_FROM != 0 && e.Mask&unix.IN_ISDIR != 0 {
+			w.removeBelow(p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths, err
+}
+
+// forget drops the watch wd, which inotify removed.
+func (w *Watcher) forget(wd int) {
+	if dir, ok := w.paths[wd]; ok {
+		delete(w.paths, wd)
+		if w.wds[dir] == wd {
+			delete(w.wds, dir)
+		}
+	}
+}
+
+// removeBelow stops watching the folder dir and the ones below it, whose
+// watches would otherwise report changes under their old paths.
+func (w *Watcher) removeBelow(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for p, wd := range w.wds {
+		if p == dir || strings.HasPrefix(p, prefix) {
+			unix.InotifyRmWatch(w.fd, uint32(wd))
+			w.forget(wd)
+		}
+	}
+}
+
+// Close stops watching, making a pending Read return.
+func (w *Watcher) Close() error {
+	return w.file.Close()
+}
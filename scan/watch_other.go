@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package scan
+
+// Watcher reports the entries that change in the folders it watches, which
+// is only available on Linux.
+type Watcher struct{}
+
+// NewWatcher returns ErrWatchUnsupported.
+func NewWatcher() (*Watcher, error) { return nil, ErrWatchUnsupported }
+
+func (w *Watcher) Add(dir string) error    { return ErrWatchUnsupported }
+func (w *Watcher) Len() int                { return 0 }
+func (w *Watcher) Read() ([]string, error) { return nil, ErrWatchUnsupported }
+func (w *Watcher) Close() error            { return nil }
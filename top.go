@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/davleop/godu/scan"
+	"github.com/spf13/cobra"
+)
+
+// topOptions are the flags of godu top on top of the scan options.
+type topOptions struct {
+	Count int
+	Dirs  bool
+	JSON  bool
+	By    string
+}
+
+var topOpts = topOptions{Count: 20}
+
+var topCmd = &cobra.Command{
+	Use:   "top [flags] [directory]",
+	Short: "List the largest files",
+	Long: `top scans a directory (the current one by default), or imports a file with -f, and lists the largest files below it with their full paths, largest first.
+
+The files are ranked by disk usage, or by apparent size with --apparent-size. With --by user or --by group, the users or groups owning the most data are listed instead. The list is written to standard output as text, or as JSON with --json. No feedback is given while scanning unless -1 or -2 is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if topOpts.Count < 0 {
+			return fmt.Errorf("invalid value %d for --count, expected 0 or more", topOpts.Count)
+		}
+		if err := opts.prepare(); err != nil {
+			return err
+		}
+		if topOpts.By != "" && topOpts.By != "user" && topOpts.By != "group" {
+			return fmt.Errorf("invalid value %q for --by, expected user or group", topOpts.By)
+		}
+		if opts.Interface == unsetInterface {
+			opts.Interface = silentInterface
+		}
+
+		dir, err := scanDir(args)
+		if err != nil {
+			return err
+		}
+		t, err := load(opts, dir)
+		if err != nil {
+			return err
+		}
+
+		if topOpts.By != "" {
+			return printOwners(os.Stdout, t, topOpts, opts)
+		}
+		entries := t.Largest(t.Root(), topOpts.Count, topOpts.Dirs, opts.UI.UseApparentSize)
+		if topOpts.JSON {
+			return json.NewEncoder(os.Stdout).Encode(entries)
+		}
+		return printEntries(os.Stdout, entries, opts)
+	},
+}
+
+func init() {
+	flags := topCmd.Flags()
+	flags.IntVarP(&topOpts.Count, "count", "n", topOpts.Count, "-n [COUNT] sets how many files, users or groups are listed")
+	flags.BoolVar(&topOpts.Dirs, "dirs", false, "--dirs lists directories along with the files. The size of a directory is the total of everything below it.")
+	flags.BoolVar(&topOpts.JSON, "json", false, "--json writes the list as a JSON array instead of text")
+	flags.StringVar(&topOpts.By, "by", "", "--by [user|group] lists the users or groups owning the most data instead of files")
+	rootCmd.AddCommand(topCmd)
+}
+
+// ownerUsage is a line of godu top --by.
+type ownerUsage struct {
+	Name         string `json:"name"`
+	Size         int64  `json:"dsize"`
+	ApparentSize int64  `json:"asize"`
+	Files        int    `json:"files"`
+}
+
+// printOwners writes the top users or groups of t.
+func printOwners(w io.Writer, t *scan.Tree, topOpts topOptions, opts Options) error {
+	groups := t.ByUser(t.Root())
+	if topOpts.By == "group" {
+		groups = t.ByGroup(t.Root())
+	}
+	size := func(g scan.TypeGroup) int64 {
+		if opts.UI.UseApparentSize {
+			return g.ApparentSize
+		}
+		return g.Size
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return size(groups[i]) > size(groups[j]) })
+	if len(groups) > topOpts.Count {
+		groups = groups[:topOpts.Count]
+	}
+
+	usage := make([]ownerUsage, 0, len(groups))
+	for _, g := range groups {
+		usage = append(usage, ownerUsage{g.Name, g.Size, g.ApparentSize, len(g.Files)})
+	}
+	if topOpts.JSON {
+		return json.NewEncoder(w).Encode(usage)
+	}
+	for _, u := range usage {
+		if _, err := fmt.Fprintf(w, "%8s %8d files  %s\n", formatSize(opts, u.Size, u.ApparentSize), u.Files, u.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatSize formats the size godu top ranks by.
+func formatSize(opts Options, size, apparentSize int64) string {
+	if opts.UI.UseApparentSize {
+		size = apparentSize
+	}
+	if opts.UI.SI {
+		return scan.PrettyPrintSizeSI(size)
+	}
+	return scan.PrettyPrintSize(size)
+}
+
+// printEntries writes one entry per line, with the size in front of the path
+// like du does. Directories end with a path separator.
+func printEntries(w io.Writer, entries []scan.Entry, opts Options) error {
+	for _, e := range entries {
+		path := e.Path
+		if e.IsDir {
+			path += string(filepath.Separator)
+		}
+		if _, err := fmt.Fprintf(w, "%8s  %s\n", formatSize(opts, e.Size, e.ApparentSize), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
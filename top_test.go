@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/davleop/godu/scan"
+)
+
+func TestTopNegativeCount(t *testing.T) {
+	defer func(saved topOptions) { topOpts = saved }(topOpts)
+	for _, by := range []string{"", "user"} {
+		topOpts = topOptions{Count: -1, By: by}
+		if err := topCmd.RunE(topCmd, []string{t.TempDir()}); err == nil {
+			t.Errorf("godu top -n -1 --by %q succeeded", by)
+		}
+	}
+}
+
+func TestPrintOwners(t *testing.T) {
+	// 4242 has the most data by apparent size, 4243 by disk usage
+	tree, err := scan.ScanTree(".", scan.ScanOptions{FS: fstest.MapFS{
+		"a": {Data: make([]byte, 3000), Sys: &scan.Stat{DiskUsage: 4096, UID: 4242, GID: 4343}},
+		"b": {Data: make([]byte, 2000), Sys: &scan.Stat{DiskUsage: 4096, UID: 4242, GID: 4343}},
+		"c": {Data: make([]byte, 100), Sys: &scan.Stat{DiskUsage: 12288, UID: 4243, GID: 4344}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, other := scan.UserName(4242), scan.UserName(4243)
+	tests := []struct {
+		name     string
+		top      topOptions
+		apparent bool
+		want     []ownerUsage
+	}{
+		{"by user", topOptions{Count: 20, By: "user"}, false, []ownerUsage{{other, 12288, 100, 1}, {user, 8192, 5000, 2}}},
+		{"by apparent size", topOptions{Count: 20, By: "user"}, true, []ownerUsage{{user, 8192, 5000, 2}, {other, 12288, 100, 1}}},
+		{"by group", topOptions{Count: 1, By: "group"}, false, []ownerUsage{{scan.GroupName(4344), 12288, 100, 1}}},
+		{"none", topOptions{Count: 0, By: "user"}, false, []ownerUsage{}},
+	}
+	for _, tt := range tests {
+		o := defaultOptions()
+		o.UI.UseApparentSize = tt.apparent
+
+		var out bytes.Buffer
+		tt.top.JSON = true
+		if err := printOwners(&out, tree, tt.top, o); err != nil {
+			t.Fatal(err)
+		}
+		var got []ownerUsage
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: JSON = %+v, want %+v", tt.name, got, tt.want)
+		}
+
+		out.Reset()
+		tt.top.JSON = false
+		if err := printOwners(&out, tree, tt.top, o); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if len(tt.want) == 0 {
+			if out.Len() != 0 {
+				t.Errorf("%s: text = %q, want nothing", tt.name, out.String())
+			}
+			continue
+		}
+		if len(lines) != len(tt.want) {
+			t.Fatalf("%s: %d lines, want %d:\n%s", tt.name, len(lines), len(tt.want), out.String())
+		}
+		for i, w := range tt.want {
+			fields := strings.Fields(lines[i])
+			if fields[len(fields)-1] != w.Name || fields[len(fields)-3] != strconv.Itoa(w.Files) {
+				t.Errorf("%s: line %d = %q, want %s with %d files", tt.name, i, lines[i], w.Name, w.Files)
+			}
+		}
+	}
+}